@@ -0,0 +1,35 @@
+// Command glox-dap is a debug adapter for Lox speaking the Debug Adapter
+// Protocol over stdio.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/vdinovi/glox/lox"
+)
+
+func main() {
+	logLevel := flag.String("log", "", "enable logging to stderr at specified level")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s\n       serves DAP requests on stdin and stdout.\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// stdout carries the protocol, so logs may only go to stderr
+	lox.DisableLogger()
+	if *logLevel != "" {
+		lox.SetConsoleLogOutput(os.Stderr)
+		if err := lox.SetLogLevel(*logLevel); err != nil {
+			lox.ExitErr(err)
+		}
+	}
+	srv := newServer(bufio.NewReader(os.Stdin), os.Stdout)
+	if err := srv.run(); err != nil {
+		lox.ExitErr(err)
+	}
+	lox.Exit(lox.ExitCodeOK)
+}
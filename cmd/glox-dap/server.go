@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/vdinovi/glox/lox"
+)
+
+type server struct {
+	in         *bufio.Reader
+	out        io.Writer
+	mu         sync.Mutex // guards out and seq, as the program writes events
+	seq        int
+	lineBase   int // value of the first line in the client's positions
+	columnBase int // value of the first column in the client's positions
+	stepper    *lox.Stepper
+	session    *session
+	configured bool                    // whether configurationDone was received
+	sources    map[string][]breakpoint // breakpoints requested in each source, by path
+	breakpoint int                     // identifier of the last breakpoint requested
+}
+
+func newServer(in *bufio.Reader, out io.Writer) *server {
+	return &server{
+		in:         in,
+		out:        out,
+		lineBase:   1,
+		columnBase: 1,
+		stepper:    lox.NewStepper(),
+		sources:    map[string][]breakpoint{},
+	}
+}
+
+// Serves requests until the client disconnects or closes the input
+func (s *server) run() error {
+	defer func() {
+		if s.session != nil {
+			s.session.terminate()
+		}
+	}()
+	for {
+		body, err := readMessage(s.in)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			return err
+		}
+		log.Debug().Msgf("(dap) %s", req.Command)
+		result, handleErr := s.handle(req)
+		if err := s.reply(req, result, handleErr); err != nil {
+			return err
+		}
+		switch req.Command {
+		case "initialize":
+			if err := s.notify("initialized", nil); err != nil {
+				return err
+			}
+		case "launch":
+			if handleErr == nil {
+				if err := s.verifyBreakpoints(); err != nil {
+					return err
+				}
+			}
+		case "disconnect":
+			return nil
+		}
+	}
+}
+
+func (s *server) write(msg func(seq int) any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq += 1
+	return writeMessage(s.out, msg(s.seq))
+}
+
+func (s *server) reply(req request, body any, err error) error {
+	return s.write(func(seq int) any {
+		resp := response{Seq: seq, Type: "response", RequestSeq: req.Seq, Command: req.Command, Success: err == nil, Body: body}
+		if err != nil {
+			resp.Message = err.Error()
+		}
+		return resp
+	})
+}
+
+func (s *server) notify(name string, body any) error {
+	return s.write(func(seq int) any {
+		return event{Seq: seq, Type: "event", Event: name, Body: body}
+	})
+}
+
+// Converts a line of the source to the client's numbering
+func (s *server) line(line int) int {
+	return line - 1 + s.lineBase
+}
+
+// Converts a column of the source to the client's numbering
+func (s *server) column(column int) int {
+	return column - 1 + s.columnBase
+}
+
+func (s *server) handle(req request) (any, error) {
+	switch req.Command {
+	case "initialize":
+		var args initializeArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		if args.LinesStartAt1 != nil && !*args.LinesStartAt1 {
+			s.lineBase = 0
+		}
+		if args.ColumnsStartAt1 != nil && !*args.ColumnsStartAt1 {
+			s.columnBase = 0
+		}
+		return map[string]any{
+			"supportsConfigurationDoneRequest": true,
+			"supportsEvaluateForHovers":        true,
+			"supportsTerminateRequest":         true,
+		}, nil
+	case "launch":
+		var args launchArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		if s.session != nil {
+			return nil, fmt.Errorf("a program is already launched")
+		}
+		sess, err := newSession(s, args, s.stepper)
+		if err != nil {
+			return nil, err
+		}
+		s.session = sess
+		s.applyBreakpoints()
+		if s.configured {
+			sess.start()
+		}
+		return nil, nil
+	case "configurationDone":
+		s.configured = true
+		if s.session != nil {
+			s.session.start()
+		}
+		return nil, nil
+	case "setBreakpoints":
+		var args setBreakpointsArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return map[string]any{"breakpoints": s.setBreakpoints(args)}, nil
+	case "setExceptionBreakpoints":
+		return map[string]any{"breakpoints": []breakpoint{}}, nil
+	case "threads":
+		return map[string]any{"threads": []thread{{ID: mainThread, Name: "main"}}}, nil
+	case "stackTrace":
+		sess, err := s.launched()
+		if err != nil {
+			return nil, err
+		}
+		var frames []stackFrame
+		err = sess.inspect(func(ctx *lox.Context) {
+			frames = sess.stackTrace(ctx)
+		})
+		return map[string]any{"stackFrames": frames, "totalFrames": len(frames)}, err
+	case "scopes":
+		var args scopesArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		sess, err := s.launched()
+		if err != nil {
+			return nil, err
+		}
+		scopes, err := sess.scopes(args.FrameID)
+		return map[string]any{"scopes": scopes}, err
+	case "variables":
+		var args variablesArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		sess, err := s.launched()
+		if err != nil {
+			return nil, err
+		}
+		vars, err := sess.variables(args.VariablesReference)
+		return map[string]any{"variables": vars}, err
+	case "evaluate":
+		var args evaluateArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		sess, err := s.launched()
+		if err != nil {
+			return nil, err
+		}
+		var val lox.Value
+		var evalErr error
+		if err := sess.inspect(func(ctx *lox.Context) {
+			val, evalErr = ctx.EvaluateSource(args.Expression)
+		}); err != nil {
+			return nil, err
+		} else if evalErr != nil {
+			return nil, evalErr
+		}
+		return map[string]any{"result": val.String(), "type": val.Type().Name(), "variablesReference": 0}, nil
+	case "continue", "next", "stepIn", "stepOut":
+		sess, err := s.launched()
+		if err != nil {
+			return nil, err
+		}
+		mode := map[string]lox.StepMode{
+			"continue": lox.StepContinue,
+			"next":     lox.StepOver,
+			"stepIn":   lox.StepInto,
+			"stepOut":  lox.StepOut,
+		}[req.Command]
+		if err := sess.resume(mode); err != nil {
+			return nil, err
+		}
+		if mode == lox.StepContinue {
+			return map[string]any{"allThreadsContinued": true}, nil
+		}
+		return nil, nil
+	case "pause":
+		sess, err := s.launched()
+		if err != nil {
+			return nil, err
+		}
+		sess.pause()
+		return nil, nil
+	case "terminate", "disconnect":
+		if s.session != nil {
+			s.session.terminate()
+			s.session = nil
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported request %q", req.Command)
+}
+
+// Returns the launched program
+func (s *server) launched() (*session, error) {
+	if s.session == nil {
+		return nil, fmt.Errorf("no program is launched")
+	}
+	return s.session, nil
+}
+
+// Replaces the breakpoints of the source with those requested. As only the
+// launched program is debugged, breakpoints in other sources are unverified,
+// as are those requested before launch until the program is known.
+func (s *server) setBreakpoints(args setBreakpointsArguments) []breakpoint {
+	path := sourcePath(args.Source)
+	verified := s.session != nil && path == s.session.path
+	breakpoints := make([]breakpoint, len(args.Breakpoints))
+	for i, bp := range args.Breakpoints {
+		s.breakpoint += 1
+		breakpoints[i] = breakpoint{ID: s.breakpoint, Verified: verified, Line: bp.Line, Source: args.Source}
+	}
+	if path != "" {
+		s.sources[path] = breakpoints
+	}
+	if verified {
+		s.applyBreakpoints()
+	}
+	return breakpoints
+}
+
+// Sets the breakpoints requested in the launched program's source
+func (s *server) applyBreakpoints() {
+	s.session.mu.Lock()
+	defer s.session.mu.Unlock()
+	s.stepper.ClearBreakpoints()
+	for _, bp := range s.sources[s.session.path] {
+		s.stepper.SetBreakpoint(bp.Line + 1 - s.lineBase)
+	}
+}
+
+// Notifies the client that the breakpoints requested in the launched
+// program's source before launch are verified
+func (s *server) verifyBreakpoints() error {
+	for i, bp := range s.sources[s.session.path] {
+		if bp.Verified {
+			continue
+		}
+		bp.Verified = true
+		s.sources[s.session.path][i] = bp
+		if err := s.notify("breakpoint", breakpointEvent{Reason: "changed", Breakpoint: bp}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the absolute path of the source, or "" if it has none
+func sourcePath(src source) string {
+	if src.Path == "" {
+		return ""
+	}
+	path, err := filepath.Abs(src.Path)
+	if err != nil {
+		return src.Path
+	}
+	return path
+}
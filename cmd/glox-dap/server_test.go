@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vdinovi/glox/lox"
+)
+
+func init() {
+	lox.DisableLogger()
+}
+
+// A message received from the server, being a response or an event
+type message struct {
+	Seq        int             `json:"seq"`
+	Type       string          `json:"type"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Command    string          `json:"command"`
+	Message    string          `json:"message"`
+	Event      string          `json:"event"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// A DAP client driving a server over pipes
+type client struct {
+	t        *testing.T
+	requests *io.PipeWriter
+	messages chan message
+	events   []message // events received while awaiting a response
+	seq      int
+	done     chan error
+}
+
+func newClient(t *testing.T) *client {
+	reqR, reqW := io.Pipe()
+	respR, respW := io.Pipe()
+	c := &client{t: t, requests: reqW, messages: make(chan message, 64), done: make(chan error, 1)}
+	srv := newServer(bufio.NewReader(reqR), respW)
+	go func() {
+		err := srv.run()
+		respW.Close()
+		c.done <- err
+	}()
+	go func() {
+		defer close(c.messages)
+		in := bufio.NewReader(respR)
+		for {
+			body, err := readMessage(in)
+			if err != nil {
+				return
+			}
+			var msg message
+			if err := json.Unmarshal(body, &msg); err != nil {
+				t.Errorf("Invalid message %s: %s", body, err)
+				return
+			}
+			c.messages <- msg
+		}
+	}()
+	t.Cleanup(func() {
+		reqW.Close()
+		select {
+		case <-c.done:
+		case <-time.After(5 * time.Second):
+			t.Error("Expected the server to stop")
+		}
+	})
+	return c
+}
+
+func (c *client) next() message {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("Expected a message, but the server closed its output")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("Timed out awaiting a message")
+	}
+	return message{}
+}
+
+// Sends a request and returns its successful response, into whose body
+// out is decoded if not nil
+func (c *client) request(command string, args any, out any) message {
+	c.t.Helper()
+	msg := c.call(command, args)
+	if !msg.Success {
+		c.t.Fatalf("Expected %s to succeed, but got %q", command, msg.Message)
+	}
+	if out != nil {
+		if err := json.Unmarshal(msg.Body, out); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+	return msg
+}
+
+// Sends a request and returns its response, whether or not it succeeded
+func (c *client) call(command string, args any) message {
+	c.t.Helper()
+	c.seq += 1
+	req := map[string]any{"seq": c.seq, "type": "request", "command": command}
+	if args != nil {
+		req["arguments"] = args
+	}
+	if err := writeMessage(c.requests, req); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		msg := c.next()
+		if msg.Type == "event" {
+			c.events = append(c.events, msg)
+			continue
+		}
+		if msg.RequestSeq != c.seq || msg.Command != command {
+			c.t.Fatalf("Expected a response to %s, but got %+v", command, msg)
+		}
+		return msg
+	}
+}
+
+// Returns the next event, into whose body out is decoded if not nil,
+// failing unless it is named name
+func (c *client) event(name string, out any) {
+	c.t.Helper()
+	var msg message
+	if len(c.events) > 0 {
+		msg, c.events = c.events[0], c.events[1:]
+	} else {
+		msg = c.next()
+	}
+	if msg.Type != "event" || msg.Event != name {
+		c.t.Fatalf("Expected %s event, but got %+v", name, msg)
+	}
+	if out != nil {
+		if err := json.Unmarshal(msg.Body, out); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+}
+
+const debuggeeSource = `var a = 1;
+fun f(x) {
+	var y = x + a;
+	return y;
+}
+print f(2);
+print a;
+`
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	program := filepath.Join(dir, "main.lox")
+	if err := os.WriteFile(program, []byte(debuggeeSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	other := source{Path: filepath.Join(dir, "other.lox")}
+	c := newClient(t)
+
+	var capabilities map[string]bool
+	c.request("initialize", map[string]any{"linesStartAt1": true, "columnsStartAt1": true}, &capabilities)
+	if !capabilities["supportsConfigurationDoneRequest"] {
+		t.Errorf("Expected configurationDone to be supported, but got %v", capabilities)
+	}
+	c.event("initialized", nil)
+
+	// breakpoints requested before launch are verified once the program is known
+	var bps struct{ Breakpoints []breakpoint }
+	c.request("setBreakpoints", setBreakpointsArguments{
+		Source: source{Path: program}, Breakpoints: []sourceBreakpoint{{Line: 3}},
+	}, &bps)
+	expected := []breakpoint{{ID: 1, Line: 3, Source: source{Path: program}}}
+	if !reflect.DeepEqual(bps.Breakpoints, expected) {
+		t.Errorf("Expected breakpoints %+v, but got %+v", expected, bps.Breakpoints)
+	}
+	c.request("launch", launchArguments{Program: program}, nil)
+	var changed breakpointEvent
+	c.event("breakpoint", &changed)
+	expectedChange := breakpointEvent{Reason: "changed", Breakpoint: breakpoint{ID: 1, Verified: true, Line: 3, Source: source{Path: program}}}
+	if changed != expectedChange {
+		t.Errorf("Expected breakpoint event %+v, but got %+v", expectedChange, changed)
+	}
+
+	// breakpoints of other sources neither apply nor clear the program's
+	c.request("setBreakpoints", setBreakpointsArguments{Source: other, Breakpoints: []sourceBreakpoint{{Line: 7}}}, &bps)
+	expected = []breakpoint{{ID: 2, Line: 7, Source: other}}
+	if !reflect.DeepEqual(bps.Breakpoints, expected) {
+		t.Errorf("Expected breakpoints %+v, but got %+v", expected, bps.Breakpoints)
+	}
+	c.request("setBreakpoints", setBreakpointsArguments{Source: other}, &bps)
+	if len(bps.Breakpoints) != 0 {
+		t.Errorf("Expected no breakpoints, but got %+v", bps.Breakpoints)
+	}
+
+	c.request("configurationDone", nil, nil)
+	var stopped stoppedEvent
+	c.event("stopped", &stopped)
+	if expected := (stoppedEvent{Reason: "breakpoint", ThreadID: mainThread, AllThreadsStopped: true}); stopped != expected {
+		t.Errorf("Expected stopped event %+v, but got %+v", expected, stopped)
+	}
+
+	var trace struct {
+		StackFrames []stackFrame
+		TotalFrames int
+	}
+	c.request("stackTrace", map[string]any{"threadId": mainThread}, &trace)
+	src := source{Name: "main.lox", Path: program}
+	frames := []stackFrame{
+		{ID: 1, Name: "f", Source: src, Line: 3, Column: 6},
+		{ID: 0, Name: "<script>", Source: src, Line: 6, Column: 8},
+	}
+	if !reflect.DeepEqual(trace.StackFrames, frames) || trace.TotalFrames != len(frames) {
+		t.Errorf("Expected stack frames %+v, but got %+v", frames, trace.StackFrames)
+	}
+
+	var scopes struct{ Scopes []scope }
+	c.request("scopes", scopesArguments{FrameID: 1}, &scopes)
+	if len(scopes.Scopes) < 2 || scopes.Scopes[0].Name != "Locals" || scopes.Scopes[len(scopes.Scopes)-1].Name != "Globals" {
+		t.Fatalf("Expected scopes from Locals to Globals, but got %+v", scopes.Scopes)
+	}
+	var vars struct{ Variables []variable }
+	c.request("variables", variablesArguments{VariablesReference: scopes.Scopes[0].VariablesReference}, &vars)
+	locals := []variable{{Name: "x", Value: "2", Type: "Numeric"}}
+	if !reflect.DeepEqual(vars.Variables, locals) {
+		t.Errorf("Expected locals %+v, but got %+v", locals, vars.Variables)
+	}
+	globals := scopes.Scopes[len(scopes.Scopes)-1]
+	c.request("variables", variablesArguments{VariablesReference: globals.VariablesReference}, &vars)
+	values := map[string]string{}
+	for _, v := range vars.Variables {
+		values[v.Name] = v.Value
+	}
+	if _, ok := values["f"]; !ok || values["a"] != "1" {
+		t.Errorf("Expected globals to include a = 1 and f, but got %v", values)
+	}
+
+	c.request("next", map[string]any{"threadId": mainThread}, nil)
+	c.event("stopped", &stopped)
+	if stopped.Reason != "step" {
+		t.Errorf("Expected to stop after a step, but got %+v", stopped)
+	}
+	c.request("stackTrace", map[string]any{"threadId": mainThread}, &trace)
+	if len(trace.StackFrames) == 0 || trace.StackFrames[0].Line != 4 {
+		t.Errorf("Expected to step to line 4, but got %+v", trace.StackFrames)
+	}
+
+	c.request("continue", map[string]any{"threadId": mainThread}, nil)
+	var output outputEvent
+	for _, expected := range []string{"3", "1"} {
+		c.event("output", &output)
+		if strings.TrimSpace(output.Output) != expected || output.Category != "stdout" {
+			t.Errorf("Expected output %q, but got %+v", expected, output)
+		}
+	}
+	var exited exitedEvent
+	c.event("exited", &exited)
+	if exited.ExitCode != 0 {
+		t.Errorf("Expected exit code 0, but got %d", exited.ExitCode)
+	}
+	c.event("terminated", nil)
+	c.request("disconnect", nil, nil)
+}
+
+// Requests for the variables of a running program are refused rather than
+// reading its environments as it writes them. Run with -race.
+func TestServerInspectRunning(t *testing.T) {
+	program := filepath.Join(t.TempDir(), "loop.lox")
+	if err := os.WriteFile(program, []byte("var i = 0;\nwhile (true) {\n\ti = i + 1;\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := newClient(t)
+	c.request("initialize", map[string]any{"linesStartAt1": true, "columnsStartAt1": true}, nil)
+	c.event("initialized", nil)
+	c.request("launch", launchArguments{Program: program, StopOnEntry: true}, nil)
+	c.request("configurationDone", nil, nil)
+	var stopped stoppedEvent
+	c.event("stopped", &stopped)
+	if stopped.Reason != "entry" {
+		t.Fatalf("Expected to stop on entry, but got %+v", stopped)
+	}
+	var scopes struct{ Scopes []scope }
+	c.request("scopes", scopesArguments{FrameID: 0}, &scopes)
+	if len(scopes.Scopes) == 0 {
+		t.Fatal("Expected the scopes of the entry frame")
+	}
+	ref := scopes.Scopes[0].VariablesReference
+
+	c.request("continue", map[string]any{"threadId": mainThread}, nil)
+	for i := 0; i < 20; i++ {
+		if msg := c.call("variables", variablesArguments{VariablesReference: ref}); msg.Success || msg.Message != errNotPaused.Error() {
+			t.Fatalf("Expected variables of a running program to be refused, but got %+v", msg)
+		}
+		if msg := c.call("scopes", scopesArguments{FrameID: 0}); msg.Success || msg.Message != errNotPaused.Error() {
+			t.Fatalf("Expected scopes of a running program to be refused, but got %+v", msg)
+		}
+	}
+
+	c.request("pause", map[string]any{"threadId": mainThread}, nil)
+	c.event("stopped", &stopped)
+	if stopped.Reason != "pause" {
+		t.Fatalf("Expected to stop on pause, but got %+v", stopped)
+	}
+	c.request("scopes", scopesArguments{FrameID: 0}, &scopes)
+	var vars struct{ Variables []variable }
+	c.request("variables", variablesArguments{VariablesReference: scopes.Scopes[len(scopes.Scopes)-1].VariablesReference}, &vars)
+	found := false
+	for _, v := range vars.Variables {
+		found = found || v.Name == "i"
+	}
+	if !found {
+		t.Errorf("Expected the globals to include i, but got %+v", vars.Variables)
+	}
+	c.request("disconnect", nil, nil)
+}
+
+func TestReadMessage(t *testing.T) {
+	tests := []struct {
+		input string
+		body  string
+		err   bool
+	}{
+		{input: "Content-Length: 2\r\n\r\n{}", body: "{}"},
+		{input: "Content-Length: 2\r\nContent-Type: application/json\r\n\r\n{}", body: "{}"},
+		{input: "Content-Length: x\r\n\r\n{}", err: true},
+		{input: "Content-Length: -1\r\n\r\n{}", err: true},
+		{input: "Content-Length: 99999999999\r\n\r\n{}", err: true},
+		{input: "\r\n{}", err: true},
+		{input: "Content-Length: 4\r\n\r\n{}", err: true},
+	}
+	for _, test := range tests {
+		body, err := readMessage(bufio.NewReader(strings.NewReader(test.input)))
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected reading %q to fail, but got %q", test.input, body)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("Unexpected error reading %q: %s", test.input, err)
+			continue
+		}
+		if string(body) != test.body {
+			t.Errorf("Expected reading %q to yield %q, but got %q", test.input, test.body, body)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/vdinovi/glox/lox"
+)
+
+// The only thread of a debugged program
+const mainThread = 1
+
+var errNotPaused = errors.New("program is not paused")
+
+// Directs the paused program to resume or terminate, or runs an inspection
+// of it on the program's goroutine
+type command struct {
+	mode      lox.StepMode
+	terminate bool
+	inspect   func(ctx *lox.Context)
+	done      chan struct{}
+}
+
+// A program launched under the debugger. It runs on its own goroutine,
+// blocking in the hook while paused until a command resumes it.
+type session struct {
+	srv         *server
+	path        string
+	src         string
+	interp      *lox.Interpreter
+	cancel      context.CancelFunc
+	commands    chan command
+	done        chan struct{}
+	stopOnEntry bool
+	running     bool // whether the program was started
+
+	mu        sync.Mutex // guards the fields below
+	stepper   *lox.Stepper
+	started   bool
+	paused    bool
+	interrupt bool // whether a pause was requested
+	stopped   bool // whether termination was requested
+	stmt      lox.Statement
+	envs      []*lox.Env // environment of each active call, outermost first
+	refs      []*lox.Env // environments by variables reference, less one
+}
+
+func newSession(srv *server, args launchArguments, stepper *lox.Stepper) (*session, error) {
+	path, err := filepath.Abs(args.Program)
+	if err != nil {
+		return nil, err
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, cancel := context.WithCancel(context.Background())
+	s := &session{
+		srv:         srv,
+		path:        path,
+		src:         string(src),
+		cancel:      cancel,
+		commands:    make(chan command),
+		done:        make(chan struct{}),
+		stopOnEntry: args.StopOnEntry,
+		stepper:     stepper,
+	}
+	opts := []lox.Option{
+		lox.WithStdout(outputWriter{srv, "stdout"}),
+		lox.WithStderr(outputWriter{srv, "stderr"}),
+		lox.WithContext(c),
+		lox.WithArgs(args.Args...),
+		lox.WithEnviron(os.LookupEnv),
+		lox.WithFileAccess(filepath.Dir(path)),
+	}
+	if !args.NoDebug {
+		opts = append(opts, lox.WithHook(s))
+	}
+	if s.interp, err = lox.NewInterpreter(opts...); err != nil {
+		cancel()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Runs the program to completion on its own goroutine
+func (s *session) start() {
+	s.running = true
+	go func() {
+		defer close(s.done)
+		defer s.cancel()
+		code := lox.ExitCodeOK
+		_, err := s.interp.Eval(s.src)
+		s.mu.Lock()
+		stopped := s.stopped
+		s.mu.Unlock()
+		// a program stopped by the client exits normally however it ends
+		var exit lox.ExitError
+		switch {
+		case stopped:
+		case errors.As(err, &exit):
+			code = exit.Code
+		case err != nil:
+			code = lox.ExitCodeErr
+			s.srv.notify("output", outputEvent{Category: "stderr", Output: err.Error() + "\n"})
+		}
+		s.srv.notify("exited", exitedEvent{ExitCode: int(code)})
+		s.srv.notify("terminated", nil)
+	}()
+}
+
+func (s *session) BeforeStatement(ctx *lox.Context, stmt lox.Statement) error {
+	depth := len(ctx.Calls())
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return lox.NewExitError(lox.ExitCodeOK)
+	}
+	s.envs = append(s.envs[:min(depth, len(s.envs))], ctx.Env())
+	reason := "step"
+	if !s.started {
+		s.started = true
+		reason = "entry"
+		if !s.stopOnEntry {
+			s.stepper.Resume(ctx, lox.StepContinue)
+		}
+	}
+	pause, breakpoint := s.stepper.Pause(ctx, stmt)
+	if breakpoint {
+		reason = "breakpoint"
+	} else if s.interrupt {
+		reason = "pause"
+	}
+	if pause {
+		s.paused, s.interrupt, s.stmt = true, false, stmt
+	}
+	s.mu.Unlock()
+	if !pause {
+		return nil
+	}
+
+	s.srv.notify("stopped", stoppedEvent{Reason: reason, ThreadID: mainThread, AllThreadsStopped: true})
+	for cmd := range s.commands {
+		switch {
+		case cmd.inspect != nil:
+			cmd.inspect(ctx)
+			close(cmd.done)
+		case cmd.terminate:
+			return lox.NewExitError(lox.ExitCodeOK)
+		default:
+			s.mu.Lock()
+			s.stepper.Resume(ctx, cmd.mode)
+			s.refs = nil
+			s.mu.Unlock()
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *session) AfterStatement(ctx *lox.Context, stmt lox.Statement, err error) {
+}
+
+func (s *session) EnterFunction(ctx *lox.Context, name string, args []lox.Value) error {
+	return nil
+}
+
+func (s *session) ExitFunction(ctx *lox.Context, name string, val lox.Value, err error) {
+}
+
+// Resumes the paused program as directed by mode
+func (s *session) resume(mode lox.StepMode) error {
+	s.mu.Lock()
+	if !s.paused {
+		s.mu.Unlock()
+		return errNotPaused
+	}
+	s.paused = false
+	s.mu.Unlock()
+	s.commands <- command{mode: mode}
+	return nil
+}
+
+// Pauses the running program at its next statement
+func (s *session) pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.paused {
+		s.stepper.Interrupt()
+		s.interrupt = true
+	}
+}
+
+// Stops the program, waiting for it to exit
+func (s *session) terminate() {
+	if !s.running {
+		s.cancel()
+		return
+	}
+	s.mu.Lock()
+	paused := s.paused
+	s.paused, s.stopped = false, true
+	s.mu.Unlock()
+	if paused {
+		s.commands <- command{terminate: true}
+	} else {
+		s.cancel()
+	}
+	<-s.done
+}
+
+// Runs fn on the paused program's goroutine
+func (s *session) inspect(fn func(ctx *lox.Context)) error {
+	s.mu.Lock()
+	paused := s.paused
+	s.mu.Unlock()
+	if !paused {
+		return errNotPaused
+	}
+	done := make(chan struct{})
+	s.commands <- command{inspect: fn, done: done}
+	<-done
+	return nil
+}
+
+// Returns the active frames, innermost first. Each frame is identified by
+// its call depth.
+func (s *session) stackTrace(ctx *lox.Context) []stackFrame {
+	calls := ctx.Calls()
+	src := source{Name: filepath.Base(s.path), Path: s.path}
+	frames := make([]stackFrame, 0, len(calls)+1)
+	for depth := len(calls); depth >= 0; depth-- {
+		frame := stackFrame{ID: depth, Name: "<script>", Source: src}
+		if depth > 0 {
+			frame.Name = calls[depth-1].Function
+		}
+		pos := s.stmt.Position()
+		if depth < len(calls) {
+			pos = calls[depth].Position
+		}
+		frame.Line, frame.Column = s.srv.line(pos.Line), s.srv.column(pos.Column)
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// Returns a scope for each environment enclosing the frame, innermost
+// first, inspecting them on the paused program's goroutine
+func (s *session) scopes(frame int) (scopes []scope, err error) {
+	if ierr := s.inspect(func(*lox.Context) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if frame < 0 || frame >= len(s.envs) {
+			err = fmt.Errorf("unknown frame %d", frame)
+			return
+		}
+		for env := s.envs[frame]; env != nil; env = env.Parent() {
+			name := "Locals"
+			if env.Parent() == nil {
+				name = "Globals"
+			} else if len(scopes) > 0 {
+				name = "Enclosing"
+			}
+			s.refs = append(s.refs, env)
+			scopes = append(scopes, scope{Name: name, VariablesReference: len(s.refs)})
+		}
+	}); ierr != nil {
+		return nil, ierr
+	}
+	return scopes, err
+}
+
+// Returns the variables of the environment with the reference, read on
+// the paused program's goroutine
+func (s *session) variables(ref int) (vars []variable, err error) {
+	if ierr := s.inspect(func(*lox.Context) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if ref < 1 || ref > len(s.refs) {
+			err = fmt.Errorf("unknown variables reference %d", ref)
+			return
+		}
+		env := s.refs[ref-1]
+		vars = []variable{}
+		for _, name := range env.Names() {
+			val := env.Value(name)
+			vars = append(vars, variable{Name: name, Value: val.String(), Type: val.Type().Name()})
+		}
+	}); ierr != nil {
+		return nil, ierr
+	}
+	return vars, err
+}
+
+// Forwards program output to the client as output events
+type outputWriter struct {
+	srv      *server
+	category string
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	w.srv.notify("output", outputEvent{Category: w.category, Output: string(p)})
+	return len(p), nil
+}
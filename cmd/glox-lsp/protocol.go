@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/vdinovi/glox/lox"
+)
+
+// JSON-RPC error codes used by the protocol
+const (
+	codeParseError     = -32700
+	codeInvalidParams  = -32602
+	codeMethodNotFound = -32601
+	codeInvalidRequest = -32600
+)
+
+// Request or notification from the client. Notifications have no ID.
+type request struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *responseError   `json:"error,omitempty"`
+}
+
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// Size in bytes beyond which messages are refused
+const maxMessageSize = 64 << 20
+
+// Reads a message framed by a Content-Length header
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length: %w", err)
+	}
+	if n < 0 || n > maxMessageSize {
+		return nil, fmt.Errorf("invalid Content-Length: %d", n)
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// Writes the message framed by a Content-Length header
+func writeMessage(w io.Writer, msg any) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// Zero-based line and UTF-16 offset within the line
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type location struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     position               `json:"position"`
+}
+
+type didOpenParams struct {
+	TextDocument struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	} `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument   textDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type didCloseParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type referenceParams struct {
+	textDocumentPositionParams
+	Context struct {
+		IncludeDeclaration bool `json:"includeDeclaration"`
+	} `json:"context"`
+}
+
+type documentSymbolParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type diagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Code     string   `json:"code"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []diagnostic `json:"diagnostics"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type hover struct {
+	Contents markupContent `json:"contents"`
+	Range    lspRange      `json:"range"`
+}
+
+type documentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           int              `json:"kind"`
+	Range          lspRange         `json:"range"`
+	SelectionRange lspRange         `json:"selectionRange"`
+	Children       []documentSymbol `json:"children,omitempty"`
+}
+
+type completionItem struct {
+	Label  string `json:"label"`
+	Kind   int    `json:"kind"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Protocol constants for symbol and completion kinds
+const (
+	severityError = 1
+
+	symbolFunction = 12
+	symbolVariable = 13
+
+	completionFunction = 3
+	completionField    = 5
+	completionVariable = 6
+	completionModule   = 9
+)
+
+// Open document and its analysis
+type document struct {
+	lines    []string
+	analysis *lox.Analysis
+}
+
+func newDocument(text string) *document {
+	return &document{
+		lines:    strings.Split(text, "\n"),
+		analysis: lox.Analyze(text),
+	}
+}
+
+// Converts a one-based line and rune column to a protocol position
+func (d *document) position(pos lox.Position) position {
+	line := max(pos.Line-1, 0)
+	var text string
+	if line < len(d.lines) {
+		text = d.lines[line]
+	}
+	units, col := 0, 1
+	for _, r := range text {
+		if col >= pos.Column {
+			break
+		}
+		units += len(utf16.Encode([]rune{r}))
+		col += 1
+	}
+	return position{Line: line, Character: units + max(pos.Column-col, 0)}
+}
+
+// Converts a protocol position to a one-based line and rune column
+func (d *document) loxPosition(pos position) lox.Position {
+	var text string
+	if pos.Line < len(d.lines) {
+		text = d.lines[pos.Line]
+	}
+	units, col := 0, 1
+	for len(text) > 0 && units < pos.Character {
+		r, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+		units += len(utf16.Encode([]rune{r}))
+		col += 1
+	}
+	return lox.Position{Line: pos.Line + 1, Column: col + max(pos.Character-units, 0)}
+}
+
+func (d *document) span(span lox.Span) lspRange {
+	return lspRange{Start: d.position(span.Start), End: d.position(span.End)}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/rs/zerolog/log"
+	"github.com/vdinovi/glox/lox"
+)
+
+type server struct {
+	in       *bufio.Reader
+	out      io.Writer
+	docs     map[string]*document
+	shutdown bool // whether shutdown was requested before exit
+}
+
+func newServer(in *bufio.Reader, out io.Writer) *server {
+	return &server{in: in, out: out, docs: make(map[string]*document)}
+}
+
+// Serves messages until the client sends exit or closes the input
+func (s *server) run() error {
+	for {
+		body, err := readMessage(s.in)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.reply(nil, nil, &responseError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		log.Debug().Msgf("(lsp) %s", req.Method)
+		if req.Method == "exit" {
+			return nil
+		}
+		result, err := s.handle(req)
+		if req.ID == nil {
+			if err != nil {
+				log.Error().Msgf("(lsp) %s: %s", req.Method, err)
+			}
+			continue
+		}
+		var rerr *responseError
+		if err != nil && !errors.As(err, &rerr) {
+			rerr = &responseError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		if err := s.reply(req.ID, result, rerr); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *server) reply(id *json.RawMessage, result any, rerr *responseError) error {
+	resp := response{JSONRPC: "2.0", ID: id, Error: rerr}
+	if rerr == nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		resp.Result = data
+	}
+	return writeMessage(s.out, resp)
+}
+
+func (s *server) notify(method string, params any) error {
+	return writeMessage(s.out, notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *server) handle(req request) (any, error) {
+	if s.shutdown && req.Method != "exit" {
+		return nil, &responseError{Code: codeInvalidRequest, Message: "server is shut down"}
+	}
+	switch req.Method {
+	case "initialize":
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":       1, // full
+				"hoverProvider":          true,
+				"definitionProvider":     true,
+				"referencesProvider":     true,
+				"documentSymbolProvider": true,
+				"completionProvider":     map[string]any{"triggerCharacters": []string{"."}},
+			},
+			"serverInfo": map[string]string{"name": "glox-lsp"},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.open(params.TextDocument.URI, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			return nil, s.open(params.TextDocument.URI, params.ContentChanges[n-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return nil, s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI: params.TextDocument.URI, Diagnostics: []diagnostic{},
+		})
+	case "textDocument/hover":
+		return withPosition(s, req.Params, s.hover)
+	case "textDocument/definition":
+		return withPosition(s, req.Params, s.definition)
+	case "textDocument/completion":
+		return withPosition(s, req.Params, s.completion)
+	case "textDocument/references":
+		var params referenceParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		locations := []location{}
+		for _, span := range doc.analysis.References(doc.loxPosition(params.Position), params.Context.IncludeDeclaration) {
+			locations = append(locations, location{URI: params.TextDocument.URI, Range: doc.span(span)})
+		}
+		return locations, nil
+	case "textDocument/documentSymbol":
+		var params documentSymbolParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		return documentSymbols(doc, doc.analysis.Symbols()), nil
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+}
+
+// Decodes the document and position of the request and calls fn with them
+func withPosition[T any](s *server, raw json.RawMessage, fn func(string, *document, lox.Position) T) (any, error) {
+	var params textDocumentPositionParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+	doc, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return nil, nil
+	}
+	return fn(params.TextDocument.URI, doc, doc.loxPosition(params.Position)), nil
+}
+
+// Analyzes the document and publishes its diagnostics
+func (s *server) open(uri, text string) error {
+	doc := newDocument(text)
+	s.docs[uri] = doc
+	diagnostics := []diagnostic{}
+	for _, d := range doc.analysis.Diagnostics {
+		diagnostics = append(diagnostics, diagnostic{
+			Range:    doc.span(d.Span),
+			Severity: severityError,
+			Code:     d.Phase.String(),
+			Source:   "glox",
+			Message:  d.Message,
+		})
+	}
+	return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+}
+
+func (s *server) hover(_ string, doc *document, pos lox.Position) *hover {
+	text, span, ok := doc.analysis.Hover(pos)
+	if !ok {
+		return nil
+	}
+	return &hover{
+		Contents: markupContent{Kind: "markdown", Value: "```lox\n" + text + "\n```"},
+		Range:    doc.span(span),
+	}
+}
+
+func (s *server) definition(uri string, doc *document, pos lox.Position) *location {
+	span, ok := doc.analysis.Definition(pos)
+	if !ok {
+		return nil
+	}
+	return &location{URI: uri, Range: doc.span(span)}
+}
+
+func (s *server) completion(_ string, doc *document, pos lox.Position) []completionItem {
+	items := []completionItem{}
+	for _, c := range doc.analysis.Completions(pos) {
+		kind := completionVariable
+		switch c.Kind {
+		case lox.BindingFunction, lox.BindingNative:
+			kind = completionFunction
+		case lox.BindingModule:
+			kind = completionModule
+		case lox.BindingMember:
+			kind = completionField
+		}
+		items = append(items, completionItem{Label: c.Label, Kind: kind, Detail: c.Detail})
+	}
+	return items
+}
+
+func documentSymbols(doc *document, symbols []lox.Symbol) []documentSymbol {
+	result := []documentSymbol{}
+	for _, sym := range symbols {
+		kind := symbolVariable
+		if sym.Kind == lox.BindingFunction {
+			kind = symbolFunction
+		}
+		ds := documentSymbol{
+			Name:           sym.Name,
+			Detail:         sym.Detail,
+			Kind:           kind,
+			Range:          doc.span(sym.Span),
+			SelectionRange: doc.span(sym.NameSpan),
+		}
+		if len(sym.Children) > 0 {
+			ds.Children = documentSymbols(doc, sym.Children)
+		}
+		result = append(result, ds)
+	}
+	return result
+}
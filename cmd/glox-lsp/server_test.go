@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vdinovi/glox/lox"
+)
+
+func init() {
+	lox.DisableLogger()
+}
+
+// A message received from the server, being a response or a notification
+type message struct {
+	ID     *json.RawMessage `json:"id"`
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+	Result json.RawMessage  `json:"result"`
+	Error  *responseError   `json:"error"`
+}
+
+// An LSP client driving a server over pipes
+type client struct {
+	t             *testing.T
+	requests      *io.PipeWriter
+	messages      chan message
+	notifications []message // notifications received while awaiting a response
+	id            int
+	srv           *server
+	done          chan error
+}
+
+func newClient(t *testing.T) *client {
+	reqR, reqW := io.Pipe()
+	respR, respW := io.Pipe()
+	srv := newServer(bufio.NewReader(reqR), respW)
+	c := &client{t: t, requests: reqW, messages: make(chan message, 64), srv: srv, done: make(chan error, 1)}
+	go func() {
+		err := srv.run()
+		respW.Close()
+		c.done <- err
+	}()
+	go func() {
+		defer close(c.messages)
+		in := bufio.NewReader(respR)
+		for {
+			body, err := readMessage(in)
+			if err != nil {
+				return
+			}
+			var msg message
+			if err := json.Unmarshal(body, &msg); err != nil {
+				t.Errorf("Invalid message %s: %s", body, err)
+				return
+			}
+			c.messages <- msg
+		}
+	}()
+	t.Cleanup(func() {
+		reqW.Close()
+		select {
+		case <-c.done:
+		case <-time.After(5 * time.Second):
+			t.Error("Expected the server to stop")
+		}
+	})
+	return c
+}
+
+func (c *client) next() message {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("Expected a message, but the server closed its output")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("Timed out awaiting a message")
+	}
+	return message{}
+}
+
+func (c *client) send(method string, id *int, params any) {
+	c.t.Helper()
+	msg := map[string]any{"jsonrpc": "2.0", "method": method}
+	if id != nil {
+		msg["id"] = *id
+	}
+	if params != nil {
+		msg["params"] = params
+	}
+	if err := writeMessage(c.requests, msg); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// Sends a request and returns its response, whose result is decoded into
+// out if not nil
+func (c *client) call(method string, params any, out any) *responseError {
+	c.t.Helper()
+	c.id += 1
+	c.send(method, &c.id, params)
+	for {
+		msg := c.next()
+		if msg.ID == nil {
+			c.notifications = append(c.notifications, msg)
+			continue
+		}
+		var id int
+		if err := json.Unmarshal(*msg.ID, &id); err != nil || id != c.id {
+			c.t.Fatalf("Expected a response to %s, but got %+v", method, msg)
+		}
+		if msg.Error == nil && out != nil {
+			if err := json.Unmarshal(msg.Result, out); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		return msg.Error
+	}
+}
+
+// Sends a request, failing unless it succeeds
+func (c *client) request(method string, params any, out any) {
+	c.t.Helper()
+	if err := c.call(method, params, out); err != nil {
+		c.t.Fatalf("Expected %s to succeed, but got %s", method, err)
+	}
+}
+
+// Sends a notification
+func (c *client) notify(method string, params any) {
+	c.t.Helper()
+	c.send(method, nil, params)
+}
+
+// Returns the next notification, into whose params out is decoded, failing
+// unless it is for method
+func (c *client) notification(method string, out any) {
+	c.t.Helper()
+	var msg message
+	if len(c.notifications) > 0 {
+		msg, c.notifications = c.notifications[0], c.notifications[1:]
+	} else {
+		msg = c.next()
+	}
+	if msg.ID != nil || msg.Method != method {
+		c.t.Fatalf("Expected %s notification, but got %+v", method, msg)
+	}
+	if err := json.Unmarshal(msg.Params, out); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+const documentURI = "file:///main.lox"
+
+const documentText = `var s = "😀"; var t = s;
+fun f(a) {
+	return s + a;
+}
+print f("x");
+`
+
+func at(line, character int) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]string{"uri": documentURI},
+		"position":     position{Line: line, Character: character},
+	}
+}
+
+func span(line, start, end int) lspRange {
+	return lspRange{Start: position{line, start}, End: position{line, end}}
+}
+
+func TestServer(t *testing.T) {
+	c := newClient(t)
+
+	var init struct {
+		Capabilities map[string]any
+	}
+	c.request("initialize", map[string]any{}, &init)
+	if init.Capabilities["definitionProvider"] != true || init.Capabilities["hoverProvider"] != true {
+		t.Errorf("Expected definitions and hovers to be provided, but got %v", init.Capabilities)
+	}
+	c.notify("initialized", map[string]any{})
+
+	c.notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]string{"uri": documentURI, "text": documentText},
+	})
+	var diagnostics publishDiagnosticsParams
+	c.notification("textDocument/publishDiagnostics", &diagnostics)
+	if diagnostics.URI != documentURI || len(diagnostics.Diagnostics) != 0 {
+		t.Errorf("Expected no diagnostics, but got %+v", diagnostics)
+	}
+
+	// the emoji before the second s takes two UTF-16 code units
+	var hov hover
+	c.request("textDocument/hover", at(0, 22), &hov)
+	if expected := (hover{Contents: markupContent{Kind: "markdown", Value: "```lox\nvar s: String\n```"}, Range: span(0, 22, 23)}); hov != expected {
+		t.Errorf("Expected hover %+v, but got %+v", expected, hov)
+	}
+
+	var def location
+	c.request("textDocument/definition", at(0, 22), &def)
+	if expected := (location{URI: documentURI, Range: span(0, 4, 5)}); def != expected {
+		t.Errorf("Expected definition %+v, but got %+v", expected, def)
+	}
+	c.request("textDocument/definition", at(2, 12), &def)
+	if expected := (location{URI: documentURI, Range: span(1, 6, 7)}); def != expected {
+		t.Errorf("Expected the parameter definition %+v, but got %+v", expected, def)
+	}
+
+	var refs []location
+	params := at(0, 4)
+	params["context"] = map[string]bool{"includeDeclaration": true}
+	c.request("textDocument/references", params, &refs)
+	expectedRefs := []location{
+		{URI: documentURI, Range: span(0, 4, 5)},
+		{URI: documentURI, Range: span(0, 22, 23)},
+		{URI: documentURI, Range: span(2, 8, 9)},
+	}
+	if !reflect.DeepEqual(refs, expectedRefs) {
+		t.Errorf("Expected references %+v, but got %+v", expectedRefs, refs)
+	}
+
+	var items []completionItem
+	c.request("textDocument/completion", at(2, 1), &items)
+	kinds := map[string]int{}
+	for _, item := range items {
+		kinds[item.Label] = item.Kind
+	}
+	for label, kind := range map[string]int{"a": completionVariable, "s": completionVariable, "f": completionFunction, "math": completionModule} {
+		if kinds[label] != kind {
+			t.Errorf("Expected completion %q of kind %d, but got %v", label, kind, kinds)
+		}
+	}
+
+	var symbols []documentSymbol
+	c.request("textDocument/documentSymbol", map[string]any{"textDocument": map[string]string{"uri": documentURI}}, &symbols)
+	var names []string
+	for _, sym := range symbols {
+		names = append(names, sym.Name)
+	}
+	if expected := []string{"s", "t", "f"}; !reflect.DeepEqual(names, expected) || symbols[2].Kind != symbolFunction {
+		t.Errorf("Expected symbols %v with function f, but got %+v", expected, symbols)
+	}
+
+	c.notify("textDocument/didChange", map[string]any{
+		"textDocument":   map[string]string{"uri": documentURI},
+		"contentChanges": []map[string]string{{"text": "print 1 +;"}},
+	})
+	c.notification("textDocument/publishDiagnostics", &diagnostics)
+	expectedDiagnostics := []diagnostic{{
+		Range:    span(0, 9, 10),
+		Severity: severityError,
+		Code:     lox.PhaseParse.String(),
+		Source:   "glox",
+		Message:  lox.NewMissingTerminalError(lox.Token{}).Error(),
+	}}
+	if !reflect.DeepEqual(diagnostics.Diagnostics, expectedDiagnostics) {
+		t.Errorf("Expected diagnostics %+v, but got %+v", expectedDiagnostics, diagnostics.Diagnostics)
+	}
+
+	c.notify("textDocument/didClose", map[string]any{"textDocument": map[string]string{"uri": documentURI}})
+	c.notification("textDocument/publishDiagnostics", &diagnostics)
+	if len(diagnostics.Diagnostics) != 0 {
+		t.Errorf("Expected diagnostics to be cleared, but got %+v", diagnostics)
+	}
+	var result any = "unset"
+	c.request("textDocument/hover", at(0, 4), &result)
+	if result != nil {
+		t.Errorf("Expected no hover in a closed document, but got %v", result)
+	}
+
+	if err := c.call("textDocument/rename", at(0, 4), nil); err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("Expected an unknown method to fail with code %d, but got %v", codeMethodNotFound, err)
+	}
+	c.request("shutdown", nil, nil)
+	if err := c.call("textDocument/hover", at(0, 4), nil); err == nil || err.Code != codeInvalidRequest {
+		t.Errorf("Expected a request after shutdown to fail with code %d, but got %v", codeInvalidRequest, err)
+	}
+	c.notify("exit", nil)
+	select {
+	case err := <-c.done:
+		c.done <- err
+		if err != nil || !c.srv.shutdown {
+			t.Errorf("Expected the server to exit after shutdown, but got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected the server to exit")
+	}
+}
+
+func TestReadMessage(t *testing.T) {
+	tests := []struct {
+		input string
+		body  string
+		err   bool
+	}{
+		{input: "Content-Length: 2\r\n\r\n{}", body: "{}"},
+		{input: "Content-Length: 2\r\nContent-Type: application/vscode-jsonrpc; charset=utf-8\r\n\r\n{}", body: "{}"},
+		{input: "Content-Length: x\r\n\r\n{}", err: true},
+		{input: "Content-Length: -1\r\n\r\n{}", err: true},
+		{input: "Content-Length: 99999999999\r\n\r\n{}", err: true},
+		{input: "\r\n{}", err: true},
+		{input: "Content-Length: 4\r\n\r\n{}", err: true},
+	}
+	for _, test := range tests {
+		body, err := readMessage(bufio.NewReader(strings.NewReader(test.input)))
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected reading %q to fail, but got %q", test.input, body)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("Unexpected error reading %q: %s", test.input, err)
+			continue
+		}
+		if string(body) != test.body {
+			t.Errorf("Expected reading %q to yield %q, but got %q", test.input, test.body, body)
+		}
+	}
+}
+
+func TestDocumentPosition(t *testing.T) {
+	doc := newDocument("print \"😀é\" + x;\n\tx;")
+	tests := []struct {
+		lox   lox.Position
+		lsp   position
+		exact bool // whether lsp converts back to lox
+	}{
+		{lox: lox.Position{Line: 1, Column: 1}, lsp: position{0, 0}, exact: true},
+		{lox: lox.Position{Line: 1, Column: 8}, lsp: position{0, 7}, exact: true},
+		{lox: lox.Position{Line: 1, Column: 9}, lsp: position{0, 9}, exact: true},
+		{lox: lox.Position{Line: 1, Column: 10}, lsp: position{0, 10}, exact: true},
+		{lox: lox.Position{Line: 1, Column: 16}, lsp: position{0, 16}, exact: true},
+		{lox: lox.Position{Line: 1, Column: 18}, lsp: position{0, 18}, exact: true},
+		{lox: lox.Position{Line: 2, Column: 2}, lsp: position{1, 1}, exact: true},
+		{lox: lox.Position{Line: 4, Column: 3}, lsp: position{3, 2}, exact: true},
+		// within the surrogate pair of the emoji
+		{lox: lox.Position{Line: 1, Column: 9}, lsp: position{0, 8}},
+	}
+	for _, test := range tests {
+		if test.exact {
+			if got := doc.position(test.lox); got != test.lsp {
+				t.Errorf("Expected %s to convert to %+v, but got %+v", test.lox, test.lsp, got)
+			}
+		}
+		if got := doc.loxPosition(test.lsp); got != test.lox {
+			t.Errorf("Expected %+v to convert to %s, but got %s", test.lsp, test.lox, got)
+		}
+	}
+}
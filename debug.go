@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/vdinovi/glox/lox"
+)
+
+// Runs the file named by args under the interactive debugger, which reads
+// commands from stdin
+func debug(args []string, opts []lox.Option) error {
+	flags := flag.NewFlagSet("debug", flag.ExitOnError)
+	breaks := flags.String("break", "", "comma-separated lines on which to set breakpoints")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("debug requires a file")
+	}
+	fpath := flags.Arg(0)
+	src, err := os.ReadFile(fpath)
+	if err != nil {
+		return err
+	}
+
+	debugger := lox.NewDebugger(os.Stdin, os.Stdout)
+	debugger.SetSource(string(src))
+	if *breaks != "" {
+		for _, s := range strings.Split(*breaks, ",") {
+			line, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				return fmt.Errorf("invalid breakpoint %q", s)
+			}
+			debugger.SetBreakpoint(line)
+		}
+	}
+
+	cancel, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	interp, err := lox.NewInterpreter(append(opts, lox.WithContext(cancel), lox.WithArgs(flags.Args()[1:]...), lox.WithHook(debugger))...)
+	if err != nil {
+		return err
+	}
+	if _, err = interp.Eval(string(src)); err != nil {
+		return fatalError{err}
+	}
+	return nil
+}
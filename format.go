@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/vdinovi/glox/lox"
+)
+
+// Formats the files named by args, or stdin, writing the result to stdout
+// unless -check or -w are given
+func format(args []string) error {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	check := flags.Bool("check", false, "list files whose formatting differs and fail if there are any")
+	write := flags.Bool("w", false, "write the result to the file instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	lox.DisableLogger()
+	if flags.NArg() == 0 {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		out, err := lox.FormatSource(lox.NewContext(io.Discard), bytes.NewReader(src))
+		if err != nil {
+			return err
+		}
+		if *check {
+			if out != string(src) {
+				fmt.Println("<stdin>")
+				return lox.NewExitError(lox.ExitCodeErr)
+			}
+			return nil
+		}
+		_, err = io.WriteString(os.Stdout, out)
+		return err
+	}
+
+	unformatted := false
+	for _, path := range flags.Args() {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		out, err := lox.FormatSource(lox.NewContext(io.Discard), bytes.NewReader(src))
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		switch {
+		case *check:
+			if out != string(src) {
+				fmt.Println(path)
+				unformatted = true
+			}
+		case *write:
+			if out != string(src) {
+				if err := os.WriteFile(path, []byte(out), 0644); err != nil {
+					return err
+				}
+			}
+		default:
+			if _, err := io.WriteString(os.Stdout, out); err != nil {
+				return err
+			}
+		}
+	}
+	if unformatted {
+		return lox.NewExitError(lox.ExitCodeErr)
+	}
+	return nil
+}
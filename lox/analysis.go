@@ -0,0 +1,540 @@
+package lox
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Region of the source from Start up to but excluding End
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// Error found while analyzing a document
+type Diagnostic struct {
+	Phase   Phase
+	Span    Span
+	Message string
+}
+
+// Name which may be completed at a position
+type Completion struct {
+	Label  string
+	Kind   BindingKind
+	Detail string
+}
+
+// Declaration in the outline of a document
+type Symbol struct {
+	Name     string
+	Kind     BindingKind
+	Detail   string
+	Span     Span // extent of the declaration
+	NameSpan Span // extent of the declared name
+	Children []Symbol
+}
+
+// Results of scanning, parsing, resolving and type checking a document, used
+// to answer editor queries
+type Analysis struct {
+	Tokens      []Token
+	Program     []Statement
+	Diagnostics []Diagnostic
+	ctx         *Context
+	bindings    *Bindings
+	decls       map[*Binding]Position                     // position of each declared name
+	names       map[Position]*Binding                     // declared names by position
+	funcs       map[Position]*FunctionDefinitionStatement // functions by position
+	types       map[Position]Type                         // inferred types of names by position
+	properties  map[Position]*PropertyExpression          // property names by position
+}
+
+// Analyzes the document. Analysis continues past errors where possible, so
+// queries may be answered for documents which do not compile.
+func Analyze(text string) *Analysis {
+	ctx := NewContext(io.Discard)
+	a := &Analysis{
+		ctx:        ctx,
+		decls:      make(map[*Binding]Position),
+		names:      make(map[Position]*Binding),
+		funcs:      make(map[Position]*FunctionDefinitionStatement),
+		types:      make(map[Position]Type),
+		properties: make(map[Position]*PropertyExpression),
+	}
+	tokens, err := Scan(ctx, strings.NewReader(text))
+	if err != nil {
+		a.diagnose(PhaseLex, err)
+		return a
+	}
+	a.Tokens = tokens
+	a.Program, err = Parse(ctx, tokens)
+	parsed := err == nil
+	if err != nil {
+		a.diagnose(PhaseParse, err)
+	}
+	if err := Resolve(ctx, a.Program); err != nil {
+		a.diagnose(PhaseResolve, err)
+	}
+	a.bindings = ctx.Bindings()
+	// a partial program would report spurious type errors
+	if parsed {
+		if err := Typecheck(ctx, a.Program); err != nil {
+			a.diagnose(PhaseTypecheck, err)
+		}
+	}
+	a.index()
+	return a
+}
+
+func (a *Analysis) diagnose(phase Phase, err error) {
+	var serr SyntaxError
+	var terr TypeError
+	var rerr RuntimeError
+	pos, msg := Position{}, err.Error()
+	switch {
+	case errors.As(err, &serr):
+		pos, msg = serr.Position, serr.Err.Error()
+	case errors.As(err, &terr):
+		pos, msg = terr.Position, terr.Err.Error()
+	case errors.As(err, &rerr):
+		pos, msg = rerr.Position, rerr.Err.Error()
+	}
+	// errors at the end of input are positioned at the zero-valued EOF token
+	if pos == (Position{}) {
+		pos = Position{1, 1}
+		if n := len(a.Tokens); n > 1 {
+			pos = tokenSpan(a.Tokens[n-2]).End
+		}
+	}
+	a.Diagnostics = append(a.Diagnostics, Diagnostic{Phase: phase, Span: a.span(pos), Message: msg})
+}
+
+// Records the positions of declared names, functions and inferred types
+func (a *Analysis) index() {
+	for _, binding := range a.bindings.Declarations() {
+		pos := binding.Position
+		if tok, ok := a.nextToken(pos, TokenIdentifier, binding.Name); ok {
+			pos = tok.Position
+		}
+		a.decls[binding] = pos
+		a.names[pos] = binding
+	}
+	walkStatements(a.Program, func(node any) {
+		switch n := node.(type) {
+		case *FunctionDefinitionStatement:
+			a.funcs[n.pos] = n
+		case *DeclarationStatement:
+			a.types[n.pos] = n.expr.Type()
+		case *VariableExpression:
+			a.types[n.pos] = n.typ
+		case *AssignmentExpression:
+			a.types[n.pos] = n.right.Type()
+		case *PropertyExpression:
+			if tok, ok := a.nextToken(n.pos, TokenIdentifier, n.name); ok {
+				a.types[tok.Position] = n.typ
+				a.properties[tok.Position] = n
+			}
+		}
+	})
+}
+
+// Returns the first token of the type at or after pos, with the given lexem
+func (a *Analysis) nextToken(pos Position, typ TokenType, lexem string) (Token, bool) {
+	for _, tok := range a.Tokens {
+		if tok.Type == typ && tok.Lexem == lexem && !tok.Position.Before(pos) {
+			return tok, true
+		}
+	}
+	return Token{}, false
+}
+
+// Returns the token spanning pos, preferring a name ending at pos, as when
+// the cursor follows it, to any other token
+func (a *Analysis) tokenAt(pos Position) (Token, bool) {
+	var at Token
+	found := false
+	for _, tok := range a.Tokens {
+		if tok.Type == TokenEOF {
+			continue
+		}
+		span := tokenSpan(tok)
+		if tok.Type == TokenIdentifier && span.End == pos {
+			return tok, true
+		}
+		if !pos.Before(span.Start) && pos.Before(span.End) {
+			at, found = tok, true
+		}
+	}
+	return at, found
+}
+
+// Returns the span of the token starting at pos, or of a single character
+func (a *Analysis) span(pos Position) Span {
+	for _, tok := range a.Tokens {
+		if tok.Position == pos && tok.Type != TokenEOF {
+			return tokenSpan(tok)
+		}
+	}
+	return Span{Start: pos, End: Position{pos.Line, pos.Column + 1}}
+}
+
+func tokenSpan(tok Token) Span {
+	n := utf8.RuneCountInString(tok.Lexem)
+	switch tok.Type {
+	case TokenString, TokenComment:
+		n += 2 // quotes or slashes
+	}
+	return Span{Start: tok.Position, End: Position{tok.Position.Line, tok.Position.Column + n}}
+}
+
+// Returns the binding declared or referenced by the name at pos
+func (a *Analysis) bindingAt(pos Position) (*Binding, Token, bool) {
+	tok, ok := a.tokenAt(pos)
+	if !ok || tok.Type != TokenIdentifier || a.bindings == nil {
+		return nil, tok, false
+	}
+	if binding, ok := a.names[tok.Position]; ok {
+		return binding, tok, true
+	}
+	binding := a.bindings.Reference(tok.Position)
+	return binding, tok, binding != nil
+}
+
+// Returns the span of the declaration of the name at pos
+func (a *Analysis) Definition(pos Position) (Span, bool) {
+	binding, _, ok := a.bindingAt(pos)
+	if !ok {
+		return Span{}, false
+	}
+	return a.span(a.decls[binding]), true
+}
+
+// Returns the spans of the references to the name at pos, preceded by its
+// declaration if requested
+func (a *Analysis) References(pos Position, declaration bool) []Span {
+	binding, _, ok := a.bindingAt(pos)
+	if !ok {
+		return nil
+	}
+	var spans []Span
+	if declaration {
+		spans = append(spans, a.span(a.decls[binding]))
+	}
+	for _, ref := range binding.References {
+		spans = append(spans, a.span(ref))
+	}
+	return spans
+}
+
+// Returns a description of the name at pos and its inferred type
+func (a *Analysis) Hover(pos Position) (string, Span, bool) {
+	tok, ok := a.tokenAt(pos)
+	if !ok || tok.Type != TokenIdentifier {
+		return "", Span{}, false
+	}
+	span := tokenSpan(tok)
+	binding, _, ok := a.bindingAt(pos)
+	if ok {
+		switch binding.Kind {
+		case BindingFunction:
+			if fn, ok := a.funcs[binding.Position]; ok {
+				return functionDetail(fn), span, true
+			}
+		case BindingParameter:
+			return "parameter " + binding.Name, span, true
+		}
+		if typ, ok := a.types[tok.Position]; ok && typ != TypeNone {
+			return "var " + binding.Name + ": " + typ.Name(), span, true
+		}
+		return "var " + binding.Name, span, true
+	}
+	if prop, ok := a.properties[tok.Position]; ok {
+		if m := a.module(prop.object); m != nil {
+			return memberDetail(m, prop.name), span, true
+		}
+		if typ := a.types[tok.Position]; typ != TypeNone {
+			return prop.name + ": " + typ.Name(), span, true
+		}
+		return "", Span{}, false
+	}
+	if fn, ok := a.ctx.runtime.Function(tok.Lexem).(*BuiltinFunction); ok {
+		return fn.Signature(), span, true
+	}
+	if a.ctx.runtime.Module(tok.Lexem) != nil {
+		return "module " + tok.Lexem, span, true
+	}
+	return "", Span{}, false
+}
+
+// Returns the module referenced by the expression, unless its name is
+// shadowed by a declaration
+func (a *Analysis) module(expr Expression) *Module {
+	v, ok := expr.(*VariableExpression)
+	if !ok || a.bindings.Reference(v.pos) != nil {
+		return nil
+	}
+	return a.ctx.runtime.Module(v.name)
+}
+
+func functionDetail(fn *FunctionDefinitionStatement) string {
+	return "fun " + fn.name + "(" + strings.Join(fn.params, ", ") + ")"
+}
+
+func memberDetail(m *Module, name string) string {
+	switch member := m.Member(name).(type) {
+	case nil:
+		return ""
+	case *ValueCallable:
+		if fn, ok := member.fn.(*BuiltinFunction); ok {
+			return fn.Signature()
+		}
+	}
+	return m.name + "." + name + ": " + m.Member(name).Type().Name()
+}
+
+// Returns the names which may be completed at pos: the members of a module
+// following a dot, or otherwise the names in scope and the runtime's natives
+// and modules
+func (a *Analysis) Completions(pos Position) []Completion {
+	var prev []Token
+	for _, tok := range a.Tokens {
+		if tok.Type != TokenEOF && tok.Type != TokenComment && tokenSpan(tok).Start.Before(pos) {
+			prev = append(prev, tok)
+		}
+	}
+	// the name being typed after the dot, if any, is filtered by the editor
+	if n := len(prev); n > 0 && prev[n-1].Type == TokenIdentifier {
+		prev = prev[:n-1]
+	}
+	if n := len(prev); n > 1 && prev[n-1].Type == TokenDot && prev[n-2].Type == TokenIdentifier {
+		object := prev[n-2]
+		if m := a.ctx.runtime.Module(object.Lexem); m != nil && a.bindings.Reference(object.Position) == nil {
+			var completions []Completion
+			for _, name := range m.Members() {
+				completions = append(completions, Completion{Label: name, Kind: BindingMember, Detail: memberDetail(m, name)})
+			}
+			return completions
+		}
+		return nil
+	}
+
+	visible := make(map[string]Completion)
+	for _, stmt := range a.Program {
+		a.declared(stmt, visible)
+	}
+	a.visible(a.Program, pos, visible)
+	completions := make([]Completion, 0, len(visible))
+	for _, completion := range visible {
+		completions = append(completions, completion)
+	}
+	sort.Slice(completions, func(i, j int) bool {
+		return completions[i].Label < completions[j].Label
+	})
+	for _, name := range a.ctx.runtime.Functions() {
+		detail := ""
+		if fn, ok := a.ctx.runtime.Function(name).(*BuiltinFunction); ok {
+			detail = fn.Signature()
+		}
+		completions = append(completions, Completion{Label: name, Kind: BindingNative, Detail: detail})
+	}
+	for _, name := range a.ctx.runtime.Modules() {
+		completions = append(completions, Completion{Label: name, Kind: BindingModule, Detail: "module " + name})
+	}
+	return completions
+}
+
+// Adds the name declared by the statement, if any
+func (a *Analysis) declared(stmt Statement, visible map[string]Completion) {
+	switch s := stmt.(type) {
+	case *DeclarationStatement:
+		detail := "var " + s.name
+		if typ := s.expr.Type(); typ != TypeNone {
+			detail += ": " + typ.Name()
+		}
+		visible[s.name] = Completion{Label: s.name, Kind: BindingVariable, Detail: detail}
+	case *FunctionDefinitionStatement:
+		visible[s.name] = Completion{Label: s.name, Kind: BindingFunction, Detail: functionDetail(s)}
+	}
+}
+
+// Adds the names declared in the scopes enclosing pos. Statements have no
+// recorded end, so the statement enclosing pos is taken to be the last one
+// starting before it.
+func (a *Analysis) visible(stmts []Statement, pos Position, visible map[string]Completion) {
+	var enclosing Statement
+	for _, stmt := range stmts {
+		if pos.Before(stmt.Position()) {
+			break
+		}
+		a.declared(stmt, visible)
+		enclosing = stmt
+	}
+	switch s := enclosing.(type) {
+	case *BlockStatement:
+		a.visible(s.stmts, pos, visible)
+	case *FunctionDefinitionStatement:
+		for _, param := range s.params {
+			visible[param] = Completion{Label: param, Kind: BindingParameter, Detail: "parameter " + param}
+		}
+		a.visible(s.body, pos, visible)
+	case *ConditionalStatement:
+		if s.elseBranch != nil && !pos.Before(s.elseBranch.Position()) {
+			a.visible([]Statement{s.elseBranch}, pos, visible)
+		} else {
+			a.visible([]Statement{s.thenBranch}, pos, visible)
+		}
+	case *WhileStatement:
+		a.visible([]Statement{s.body}, pos, visible)
+	case *ForStatement:
+		if s.init != nil {
+			a.declared(s.init, visible)
+		}
+		if s.body != nil {
+			a.visible([]Statement{s.body}, pos, visible)
+		}
+	}
+}
+
+// Returns the outline of the document: its functions, including those
+// nested within them, and its global variables
+func (a *Analysis) Symbols() []Symbol {
+	var symbols []Symbol
+	for _, stmt := range a.Program {
+		if decl, ok := stmt.(*DeclarationStatement); ok {
+			span := a.span(decl.pos)
+			symbols = append(symbols, Symbol{
+				Name: decl.name, Kind: BindingVariable, Detail: decl.expr.Type().Name(), Span: span, NameSpan: span,
+			})
+		}
+	}
+	symbols = append(symbols, a.functionSymbols(a.Program)...)
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return symbols[i].Span.Start.Before(symbols[j].Span.Start)
+	})
+	return symbols
+}
+
+// Returns the symbols of the functions declared within the statements,
+// excluding those nested within other functions
+func (a *Analysis) functionSymbols(stmts []Statement) []Symbol {
+	var symbols []Symbol
+	for _, stmt := range stmts {
+		var nested []Statement
+		switch s := stmt.(type) {
+		case *FunctionDefinitionStatement:
+			nameSpan := a.span(s.pos)
+			if tok, ok := a.nextToken(s.pos, TokenIdentifier, s.name); ok {
+				nameSpan = tokenSpan(tok)
+			}
+			symbols = append(symbols, Symbol{
+				Name:     s.name,
+				Kind:     BindingFunction,
+				Detail:   functionDetail(s),
+				Span:     Span{Start: s.pos, End: a.closingBrace(s.pos)},
+				NameSpan: nameSpan,
+				Children: a.functionSymbols(s.body),
+			})
+		case *BlockStatement:
+			nested = s.stmts
+		case *ConditionalStatement:
+			nested = []Statement{s.thenBranch}
+			if s.elseBranch != nil {
+				nested = append(nested, s.elseBranch)
+			}
+		case *WhileStatement:
+			nested = []Statement{s.body}
+		case *ForStatement:
+			if s.body != nil {
+				nested = []Statement{s.body}
+			}
+		}
+		symbols = append(symbols, a.functionSymbols(nested)...)
+	}
+	return symbols
+}
+
+// Returns the position following the brace closing the first block opened
+// at or after pos
+func (a *Analysis) closingBrace(pos Position) Position {
+	depth := 0
+	for _, tok := range a.Tokens {
+		if tok.Position.Before(pos) {
+			continue
+		}
+		switch tok.Type {
+		case TokenLeftBrace:
+			depth += 1
+		case TokenRightBrace:
+			if depth -= 1; depth == 0 {
+				return tokenSpan(tok).End
+			}
+		}
+	}
+	return a.span(pos).End
+}
+
+// Calls fn with each statement and expression of the program, parents
+// before their children
+func walkStatements(stmts []Statement, fn func(any)) {
+	for _, stmt := range stmts {
+		if stmt != nil {
+			walkStatement(stmt, fn)
+		}
+	}
+}
+
+func walkStatement(stmt Statement, fn func(any)) {
+	fn(stmt)
+	switch s := stmt.(type) {
+	case *BlockStatement:
+		walkStatements(s.stmts, fn)
+	case *ConditionalStatement:
+		walkExpressions(fn, s.expr)
+		walkStatements([]Statement{s.thenBranch, s.elseBranch}, fn)
+	case *WhileStatement:
+		walkExpressions(fn, s.expr)
+		walkStatements([]Statement{s.body}, fn)
+	case *ForStatement:
+		walkStatements([]Statement{s.init}, fn)
+		walkExpressions(fn, s.cond, s.incr)
+		walkStatements([]Statement{s.body}, fn)
+	case *ExpressionStatement:
+		walkExpressions(fn, s.expr)
+	case *PrintStatement:
+		walkExpressions(fn, s.expr)
+	case *DeclarationStatement:
+		walkExpressions(fn, s.expr)
+	case *FunctionDefinitionStatement:
+		walkStatements(s.body, fn)
+	case *ReturnStatement:
+		walkExpressions(fn, s.expr)
+	}
+}
+
+func walkExpressions(fn func(any), exprs ...Expression) {
+	for _, expr := range exprs {
+		if expr == nil {
+			continue
+		}
+		fn(expr)
+		switch e := expr.(type) {
+		case *UnaryExpression:
+			walkExpressions(fn, e.right)
+		case *BinaryExpression:
+			walkExpressions(fn, e.left, e.right)
+		case *GroupingExpression:
+			walkExpressions(fn, e.expr)
+		case *AssignmentExpression:
+			walkExpressions(fn, e.right)
+		case *CallExpression:
+			walkExpressions(fn, e.callee)
+			walkExpressions(fn, e.args...)
+		case *PropertyExpression:
+			walkExpressions(fn, e.object)
+		}
+	}
+}
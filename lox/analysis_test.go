@@ -0,0 +1,134 @@
+package lox
+
+import (
+	"fmt"
+	"testing"
+)
+
+const analysisText = `var count = 1;
+fun add(a, b) {
+	var sum = a + b;
+	return sum;
+}
+print add(count, 2);
+print math.pi + len("abc");
+`
+
+func TestAnalysisDiagnostics(t *testing.T) {
+	for _, test := range []struct {
+		text string
+		want []Diagnostic
+	}{
+		{text: analysisText, want: nil},
+		{text: "var s = \"abc;", want: []Diagnostic{
+			{Phase: PhaseLex, Span: Span{Position{1, 9}, Position{1, 10}}, Message: "unterminated string"},
+		}},
+		{text: "print 1 +;", want: []Diagnostic{
+			{Phase: PhaseParse, Span: Span{Position{1, 10}, Position{1, 11}}, Message: NewMissingTerminalError(Token{}).Error()},
+		}},
+		{text: "print 1;\nvar", want: []Diagnostic{
+			{Phase: PhaseParse, Span: Span{Position{2, 4}, Position{2, 5}}, Message: NewUnexpectedTokenError("Identifier", eofToken).Error()},
+		}},
+		{text: "var x = 1;\nprint x - \"a\";", want: []Diagnostic{
+			{Phase: PhaseTypecheck, Span: Span{Position{2, 7}, Position{2, 8}}, Message: NewInvalidBinaryOperatorForTypeError(OpSubtract, TypeNumeric, TypeString).Error()},
+		}},
+	} {
+		got := Analyze(test.text).Diagnostics
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("Expected %q to yield diagnostics %v, but got %v", test.text, test.want, got)
+		}
+	}
+}
+
+func TestAnalysisQueries(t *testing.T) {
+	a := Analyze(analysisText)
+	for _, test := range []struct {
+		pos   Position
+		hover string
+		def   *Span
+		refs  int
+	}{
+		{pos: Position{1, 6}, hover: "var count: Numeric", def: &Span{Position{1, 5}, Position{1, 10}}, refs: 1},
+		{pos: Position{6, 13}, hover: "var count: Numeric", def: &Span{Position{1, 5}, Position{1, 10}}, refs: 1},
+		{pos: Position{6, 7}, hover: "fun add(a, b)", def: &Span{Position{2, 5}, Position{2, 8}}, refs: 1},
+		{pos: Position{3, 12}, hover: "parameter a", def: &Span{Position{2, 9}, Position{2, 10}}, refs: 1},
+		{pos: Position{4, 9}, hover: "var sum", def: &Span{Position{3, 6}, Position{3, 9}}, refs: 1},
+		{pos: Position{7, 12}, hover: "math.pi: Numeric"},
+		{pos: Position{7, 7}, hover: "module math"},
+		{pos: Position{7, 17}, hover: "len(String|List|Map) -> Numeric"},
+		{pos: Position{4, 12}, hover: "var sum", def: &Span{Position{3, 6}, Position{3, 9}}, refs: 1},
+		{pos: Position{6, 1}},
+	} {
+		hover, _, _ := a.Hover(test.pos)
+		if hover != test.hover {
+			t.Errorf("Expected hover at %s to be %q, but got %q", test.pos, test.hover, hover)
+		}
+		def, ok := a.Definition(test.pos)
+		if test.def == nil && ok || test.def != nil && def != *test.def {
+			t.Errorf("Expected definition at %s to be %v, but got %v", test.pos, test.def, def)
+		}
+		if refs := a.References(test.pos, false); len(refs) != test.refs {
+			t.Errorf("Expected %d references at %s, but got %v", test.refs, test.pos, refs)
+		}
+	}
+}
+
+func TestAnalysisParameterDefinition(t *testing.T) {
+	a := Analyze("fun f(f, g) {\n\treturn f(g);\n}\n")
+	for _, test := range []struct {
+		pos Position
+		def Span
+	}{
+		{pos: Position{2, 9}, def: Span{Position{1, 7}, Position{1, 8}}},
+		{pos: Position{2, 11}, def: Span{Position{1, 10}, Position{1, 11}}},
+		{pos: Position{1, 5}, def: Span{Position{1, 5}, Position{1, 6}}},
+	} {
+		if def, ok := a.Definition(test.pos); !ok || def != test.def {
+			t.Errorf("Expected definition at %s to be %v, but got %v", test.pos, test.def, def)
+		}
+	}
+}
+
+func TestAnalysisSymbols(t *testing.T) {
+	symbols := Analyze(analysisText + "fun outer() { fun inner() {} }\n").Symbols()
+	var got []string
+	for _, s := range symbols {
+		got = append(got, fmt.Sprintf("%s %s %v %d", s.Kind, s.Name, s.Span, len(s.Children)))
+	}
+	want := []string{
+		"variable count {(1,5) (1,10)} 0",
+		"function add {(2,1) (5,2)} 0",
+		"function outer {(8,1) (8,31)} 1",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected symbols %v, but got %v", want, got)
+	}
+}
+
+func TestAnalysisCompletions(t *testing.T) {
+	a := Analyze(analysisText + "print math.;\n")
+	labels := func(completions []Completion) map[string]BindingKind {
+		m := map[string]BindingKind{}
+		for _, c := range completions {
+			m[c.Label] = c.Kind
+		}
+		return m
+	}
+	inside := labels(a.Completions(Position{4, 2}))
+	for name, kind := range map[string]BindingKind{
+		"count": BindingVariable, "add": BindingFunction, "a": BindingParameter, "sum": BindingVariable,
+		"len": BindingNative, "math": BindingModule,
+	} {
+		if inside[name] != kind {
+			t.Errorf("Expected completion %q of kind %s inside add, but got %v", name, kind, inside)
+		}
+	}
+	outside := labels(a.Completions(Position{6, 1}))
+	if _, ok := outside["sum"]; ok {
+		t.Errorf("Expected no completion of local sum outside add, but got %v", outside)
+	}
+	members := labels(a.Completions(Position{8, 12}))
+	if members["pi"] != BindingMember || members["floor"] != BindingMember || len(members) != len(newMathModule().Members()) {
+		t.Errorf("Expected completion of math members, but got %v", members)
+	}
+}
@@ -0,0 +1,282 @@
+package lox
+
+import (
+	"encoding/json"
+)
+
+// Tagged JSON form shared by all statements and expressions, identified by
+// Kind. Only the fields relevant to the kind of node are present.
+type astJSON[S, E any] struct {
+	Kind     string          `json:"Kind"`
+	Position Position        `json:"Position"`
+	Type     *Type           `json:"Type,omitempty"`     // inferred type
+	Name     string          `json:"Name,omitempty"`     // variable, function or property name
+	Params   []string        `json:"Params,omitempty"`   // function parameters
+	ParamPos []Position      `json:"ParamPos,omitempty"` // positions of the function parameters
+	Operator *Operator       `json:"Operator,omitempty"` // unary or binary operator
+	Value    json.RawMessage `json:"Value,omitempty"`    // literal value
+	Expr     E               `json:"Expr,omitempty"`
+	Left     E               `json:"Left,omitempty"`
+	Right    E               `json:"Right,omitempty"`
+	Callee   E               `json:"Callee,omitempty"`
+	Object   E               `json:"Object,omitempty"`
+	Args     []E             `json:"Args,omitempty"`
+	Cond     E               `json:"Cond,omitempty"`
+	Incr     E               `json:"Incr,omitempty"`
+	Init     S               `json:"Init,omitempty"`
+	Then     S               `json:"Then,omitempty"`
+	Else     S               `json:"Else,omitempty"`
+	Body     S               `json:"Body,omitempty"`
+	Stmts    []S             `json:"Stmts,omitempty"`
+}
+
+type astNode = astJSON[Statement, Expression]
+type rawASTNode = astJSON[json.RawMessage, json.RawMessage]
+
+func (s *BlockStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(astNode{Kind: "BlockStatement", Position: s.pos, Stmts: s.stmts})
+}
+
+func (s *ConditionalStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(astNode{
+		Kind: "ConditionalStatement", Position: s.pos, Cond: s.expr, Then: s.thenBranch, Else: s.elseBranch,
+	})
+}
+
+func (s *WhileStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(astNode{Kind: "WhileStatement", Position: s.pos, Cond: s.expr, Body: s.body})
+}
+
+func (s *ForStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(astNode{
+		Kind: "ForStatement", Position: s.pos, Init: s.init, Cond: s.cond, Incr: s.incr, Body: s.body,
+	})
+}
+
+func (s *ExpressionStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(astNode{Kind: "ExpressionStatement", Position: s.pos, Expr: s.expr})
+}
+
+func (s *PrintStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(astNode{Kind: "PrintStatement", Position: s.pos, Expr: s.expr})
+}
+
+func (s *DeclarationStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(astNode{Kind: "DeclarationStatement", Position: s.pos, Name: s.name, Expr: s.expr})
+}
+
+func (s *FunctionDefinitionStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(astNode{
+		Kind: "FunctionDefinitionStatement", Position: s.pos, Type: &s.rtype, Name: s.name, Params: s.params, ParamPos: s.paramPos,
+		Stmts: s.body,
+	})
+}
+
+func (s *ReturnStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(astNode{Kind: "ReturnStatement", Position: s.pos, Type: &s.typ, Expr: s.expr})
+}
+
+func (e *UnaryExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(astNode{Kind: "UnaryExpression", Position: e.pos, Type: &e.typ, Operator: &e.op, Right: e.right})
+}
+
+func (e *BinaryExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(astNode{
+		Kind: "BinaryExpression", Position: e.pos, Type: &e.typ, Operator: &e.op, Left: e.left, Right: e.right,
+	})
+}
+
+func (e *GroupingExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(astNode{Kind: "GroupingExpression", Position: e.pos, Type: &e.typ, Expr: e.expr})
+}
+
+func (e *AssignmentExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(astNode{Kind: "AssignmentExpression", Position: e.pos, Type: &e.typ, Name: e.name, Right: e.right})
+}
+
+func (e *VariableExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(astNode{Kind: "VariableExpression", Position: e.pos, Type: &e.typ, Name: e.name})
+}
+
+func (e *CallExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(astNode{Kind: "CallExpression", Position: e.pos, Type: &e.typ, Callee: e.callee, Args: e.args})
+}
+
+func (e *PropertyExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(astNode{Kind: "PropertyExpression", Position: e.pos, Type: &e.typ, Name: e.name, Object: e.object})
+}
+
+func (e *StringExpression) MarshalJSON() ([]byte, error) {
+	return marshalLiteral("StringExpression", e, e.value)
+}
+
+func (e *NumericExpression) MarshalJSON() ([]byte, error) {
+	return marshalLiteral("NumericExpression", e, e.value)
+}
+
+func (e *BooleanExpression) MarshalJSON() ([]byte, error) {
+	return marshalLiteral("BooleanExpression", e, e.value)
+}
+
+func (e *NilExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(astNode{Kind: "NilExpression", Position: e.pos})
+}
+
+func marshalLiteral(kind string, e Expression, value any) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	typ := e.Type()
+	return json.Marshal(astNode{Kind: kind, Position: e.Position(), Type: &typ, Value: data})
+}
+
+// Decodes a program encoded as a JSON array of statements
+func UnmarshalProgram(data []byte) ([]Statement, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return unmarshalStatements(raw)
+}
+
+// Decodes a statement from its tagged JSON form
+func UnmarshalStatement(data []byte) (Statement, error) {
+	node, err := unmarshalNode(data)
+	if err != nil || node == nil {
+		return nil, err
+	}
+	var exprs [3]Expression
+	for i, raw := range []json.RawMessage{node.Expr, node.Cond, node.Incr} {
+		if exprs[i], err = UnmarshalExpression(raw); err != nil {
+			return nil, err
+		}
+	}
+	expr, cond, incr := exprs[0], exprs[1], exprs[2]
+	var stmts [4]Statement
+	for i, raw := range []json.RawMessage{node.Init, node.Then, node.Else, node.Body} {
+		if stmts[i], err = UnmarshalStatement(raw); err != nil {
+			return nil, err
+		}
+	}
+	init, then, else_, body := stmts[0], stmts[1], stmts[2], stmts[3]
+	block, err := unmarshalStatements(node.Stmts)
+	if err != nil {
+		return nil, err
+	}
+	typ := TypeNone
+	if node.Type != nil {
+		typ = *node.Type
+	}
+
+	switch node.Kind {
+	case "BlockStatement":
+		return &BlockStatement{stmts: block, pos: node.Position}, nil
+	case "ConditionalStatement":
+		return &ConditionalStatement{expr: cond, thenBranch: then, elseBranch: else_, pos: node.Position}, nil
+	case "WhileStatement":
+		return &WhileStatement{expr: cond, body: body, pos: node.Position}, nil
+	case "ForStatement":
+		return &ForStatement{init: init, cond: cond, incr: incr, body: body, pos: node.Position}, nil
+	case "ExpressionStatement":
+		return &ExpressionStatement{expr: expr, pos: node.Position}, nil
+	case "PrintStatement":
+		return &PrintStatement{expr: expr, pos: node.Position}, nil
+	case "DeclarationStatement":
+		return &DeclarationStatement{name: node.Name, expr: expr, pos: node.Position}, nil
+	case "FunctionDefinitionStatement":
+		return &FunctionDefinitionStatement{
+			name: node.Name, params: node.Params, paramPos: node.ParamPos, body: block, rtype: typ, pos: node.Position,
+		}, nil
+	case "ReturnStatement":
+		return &ReturnStatement{expr: expr, typ: typ, pos: node.Position}, nil
+	}
+	return nil, NewUnknownASTNodeError("Statement", node.Kind)
+}
+
+// Decodes an expression from its tagged JSON form
+func UnmarshalExpression(data []byte) (Expression, error) {
+	node, err := unmarshalNode(data)
+	if err != nil || node == nil {
+		return nil, err
+	}
+	var exprs [5]Expression
+	for i, raw := range []json.RawMessage{node.Expr, node.Left, node.Right, node.Callee, node.Object} {
+		if exprs[i], err = UnmarshalExpression(raw); err != nil {
+			return nil, err
+		}
+	}
+	expr, left, right, callee, object := exprs[0], exprs[1], exprs[2], exprs[3], exprs[4]
+	var args []Expression
+	for _, raw := range node.Args {
+		arg, err := UnmarshalExpression(raw)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	op := Operator{}
+	if node.Operator != nil {
+		op = *node.Operator
+	}
+	typ := TypeNone
+	if node.Type != nil {
+		typ = *node.Type
+	}
+
+	switch node.Kind {
+	case "UnaryExpression":
+		return &UnaryExpression{op: op, right: right, pos: node.Position, typ: typ}, nil
+	case "BinaryExpression":
+		return &BinaryExpression{op: op, left: left, right: right, pos: node.Position, typ: typ}, nil
+	case "GroupingExpression":
+		return &GroupingExpression{expr: expr, pos: node.Position, typ: typ}, nil
+	case "AssignmentExpression":
+		return &AssignmentExpression{name: node.Name, right: right, pos: node.Position, typ: typ}, nil
+	case "VariableExpression":
+		return &VariableExpression{name: node.Name, pos: node.Position, typ: typ}, nil
+	case "CallExpression":
+		return &CallExpression{callee: callee, args: args, pos: node.Position, typ: typ}, nil
+	case "PropertyExpression":
+		return &PropertyExpression{object: object, name: node.Name, pos: node.Position, typ: typ}, nil
+	case "StringExpression":
+		e := &StringExpression{pos: node.Position}
+		return e, json.Unmarshal(node.Value, &e.value)
+	case "NumericExpression":
+		e := &NumericExpression{pos: node.Position}
+		return e, json.Unmarshal(node.Value, &e.value)
+	case "BooleanExpression":
+		e := &BooleanExpression{pos: node.Position}
+		return e, json.Unmarshal(node.Value, &e.value)
+	case "NilExpression":
+		return &NilExpression{pos: node.Position}, nil
+	}
+	return nil, NewUnknownASTNodeError("Expression", node.Kind)
+}
+
+// Decodes the node, or returns nil if data is absent or null
+func unmarshalNode(data []byte) (*rawASTNode, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	var node rawASTNode
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+func unmarshalStatements(raw []json.RawMessage) ([]Statement, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	stmts := make([]Statement, len(raw))
+	for i, data := range raw {
+		stmt, err := UnmarshalStatement(data)
+		if err != nil {
+			return nil, err
+		}
+		stmts[i] = stmt
+	}
+	return stmts, nil
+}
@@ -0,0 +1,98 @@
+package lox
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestASTJSONRoundTrip(t *testing.T) {
+	for _, text := range []string{
+		"1 + 2 * -3;",
+		"print !(true and nil) or \"str\";",
+		"var x = 1; x = x / 2;",
+		"{ var a = 1; print a; }",
+		"if (1 < 2) print 1; else print 2;",
+		"if (1 >= 2) { print 1; }",
+		"var i = 0; while (i < 3) i = i + 1;",
+		"for (var i = 0; i < 3; i = i + 1) print i;",
+		"for (;;) {}",
+		"fun add(a, b) { return a + b; } print add(1, 2);",
+		"print math.pi; print upper(\"abc\"); print \"abc\".upper();",
+	} {
+		ctx := NewContext(&PrintSpy{})
+		tokens, err := Scan(ctx, strings.NewReader(text))
+		if err != nil {
+			t.Fatalf("Unexpected error scanning %q: %s", text, err)
+		}
+		program, err := Parse(ctx, tokens)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %q: %s", text, err)
+		}
+		if err := Typecheck(ctx, program); err != nil {
+			t.Fatalf("Unexpected error typechecking %q: %s", text, err)
+		}
+		data, err := json.Marshal(program)
+		if err != nil {
+			t.Fatalf("Unexpected error marshaling %q: %s", text, err)
+		}
+		decoded, err := UnmarshalProgram(data)
+		if err != nil {
+			t.Fatalf("Unexpected error unmarshaling %q: %s", text, err)
+		}
+		if len(decoded) != len(program) {
+			t.Fatalf("Expected %d statements from %q but got %d", len(program), text, len(decoded))
+		}
+		for i, stmt := range program {
+			if !stmt.Equals(decoded[i]) {
+				t.Errorf("Expected %q to decode to %s but got %s", text, stmt, decoded[i])
+			}
+		}
+		// positions and types are not compared by Equals
+		redata, err := json.Marshal(decoded)
+		if err != nil {
+			t.Fatalf("Unexpected error marshaling %q: %s", text, err)
+		}
+		if !bytes.Equal(data, redata) {
+			t.Errorf("Expected %q to re-encode to %s but got %s", text, data, redata)
+		}
+	}
+}
+
+func TestASTJSONEncoding(t *testing.T) {
+	expr := &BinaryExpression{
+		op:    addOp,
+		left:  &NumericExpression{value: 1, pos: Position{1, 1}},
+		right: &StringExpression{value: "a", pos: Position{1, 5}},
+		pos:   Position{1, 3},
+		typ:   TypeNumeric.Union(TypeString),
+	}
+	data, err := json.Marshal(expr)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := `{"Kind":"BinaryExpression","Position":{"Line":1,"Column":3},"Type":["Numeric","String"],` +
+		`"Operator":{"Type":2,"Lexem":"+"},` +
+		`"Left":{"Kind":"NumericExpression","Position":{"Line":1,"Column":1},"Type":["Numeric"],"Value":1},` +
+		`"Right":{"Kind":"StringExpression","Position":{"Line":1,"Column":5},"Type":["String"],"Value":"a"}}`
+	if string(data) != expected {
+		t.Errorf("Expected %s but got %s", expected, data)
+	}
+}
+
+func TestASTJSONErrors(t *testing.T) {
+	for _, test := range []struct {
+		text string
+		err  error
+	}{
+		{text: `[{"Kind":"ClassStatement"}]`, err: NewUnknownASTNodeError("Statement", "ClassStatement")},
+		{text: `[{"Kind":"PrintStatement","Expr":{"Kind":"ThisExpression"}}]`, err: NewUnknownASTNodeError("Expression", "ThisExpression")},
+		{text: `[{"Kind":"ReturnStatement","Type":["Class"]}]`, err: NewUnknownASTNodeError("Type", "Class")},
+	} {
+		_, err := UnmarshalProgram([]byte(test.text))
+		if err != test.err {
+			t.Errorf("Expected %q to fail with %v but got %v", test.text, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package lox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Root of the Crafting Interpreters test suite
+const conformanceRoot = "../test"
+
+// Directories and files of the suite which are not run, with the reason.
+// A directory entry skips everything beneath it.
+var conformanceSkips = map[string]string{
+	"benchmark":   "benchmarks have no expectations",
+	"class":       "classes are not implemented",
+	"constructor": "classes are not implemented",
+	"field":       "classes are not implemented",
+	"inheritance": "classes are not implemented",
+	"method":      "classes are not implemented",
+	"super":       "classes are not implemented",
+	"this":        "classes are not implemented",
+	"expressions": "requires the chapter 6 and 7 harness",
+	"scanning":    "requires the chapter 4 harness",
+	"limit":       "limits are specific to clox",
+
+	"program.lox":                               "sample program rather than a test",
+	"assignment/associativity.lox":              "chained assignment does not typecheck",
+	"assignment/syntax.lox":                     "chained assignment does not typecheck",
+	"assignment/to_this.lox":                    "classes are not implemented",
+	"bool/equality.lox":                         "equality of different types is a type error",
+	"call/object.lox":                           "classes are not implemented",
+	"closure/assign_to_shadowed_later.lox":      "variables are resolved dynamically",
+	"closure/close_over_function_parameter.lox": "variables declared without a value are typed nil",
+	"closure/close_over_method_parameter.lox":   "classes are not implemented",
+	"closure/nested_closure.lox":                "variables declared without a value are typed nil",
+	"function/local_mutual_recursion.lox":       "variables are resolved dynamically",
+	"function/body_must_be_block.lox":           "syntax errors do not name the construct expected",
+	"function/missing_comma_in_parameters.lox":  "syntax errors do not name the construct expected",
+	"function/print.lox":                        "functions print as Callable(name)",
+	"function/too_many_arguments.lox":           "the argument limit is reported without its token",
+	"function/too_many_parameters.lox":          "the parameter limit is not enforced",
+	"number/decimal_point_at_eof.lox":           "a trailing decimal point is accepted",
+	"number/nan_equality.lox":                   "zero divided by zero is zero",
+	"number/trailing_dot.lox":                   "a trailing decimal point is accepted",
+	"operator/equals.lox":                       "equality of different types is a type error",
+	"operator/equals_class.lox":                 "classes are not implemented",
+	"operator/equals_method.lox":                "classes are not implemented",
+	"operator/not_class.lox":                    "classes are not implemented",
+	"operator/not_equals.lox":                   "equality of different types is a type error",
+	"regression/394.lox":                        "classes are not implemented",
+	"return/at_top_level.lox":                   "a top-level return is reported at runtime",
+	"return/in_method.lox":                      "classes are not implemented",
+	"variable/collide_with_parameter.lox":       "the resolver does not report redeclarations",
+	"variable/duplicate_local.lox":              "the resolver does not report redeclarations",
+	"variable/duplicate_parameter.lox":          "the resolver does not report redeclarations",
+	"variable/early_bound.lox":                  "variables are resolved dynamically",
+	"variable/local_from_method.lox":            "classes are not implemented",
+	"variable/unreached_undefined.lox":          "undefined variables are reported before execution",
+	"variable/use_false_as_var.lox":             "syntax errors do not name the construct expected",
+	"variable/use_local_in_initializer.lox":     "the resolver does not report self-reference in initializers",
+	"variable/use_nil_as_var.lox":               "syntax errors do not name the construct expected",
+	"variable/use_this_as_var.lox":              "syntax errors do not name the construct expected",
+}
+
+var (
+	expectOutputRe       = regexp.MustCompile(`// expect: ?(.*)`)
+	expectRuntimeErrorRe = regexp.MustCompile(`// expect runtime error: (.+)`)
+	expectErrorRe        = regexp.MustCompile(`// (Error.*)`)
+	expectLineErrorRe    = regexp.MustCompile(`// \[(java )?line (\d+)\] (Error.*)`)
+)
+
+// An error expected by an annotation
+type conformanceError struct {
+	line    int
+	message string
+}
+
+// Expectations parsed from the annotations of a test file
+type conformanceExpect struct {
+	output       []string
+	runtimeError *conformanceError  // expected runtime error, if any
+	errors       []conformanceError // expected static errors
+}
+
+func parseConformanceExpect(data []byte) conformanceExpect {
+	var expect conformanceExpect
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if m := expectOutputRe.FindStringSubmatch(text); m != nil {
+			expect.output = append(expect.output, m[1])
+		} else if m := expectRuntimeErrorRe.FindStringSubmatch(text); m != nil {
+			expect.runtimeError = &conformanceError{line: line, message: m[1]}
+		} else if m := expectLineErrorRe.FindStringSubmatch(text); m != nil {
+			n, _ := strconv.Atoi(m[2])
+			expect.errors = append(expect.errors, conformanceError{line: n, message: m[3]})
+		} else if m := expectErrorRe.FindStringSubmatch(text); m != nil {
+			expect.errors = append(expect.errors, conformanceError{line: line, message: m[1]})
+		}
+	}
+	return expect
+}
+
+// Runs the file through each phase, returning its output and the phase in
+// which it failed, if any
+func runConformance(data []byte) (output []string, phase Phase, err error) {
+	var stdout bytes.Buffer
+	ctx := NewContext(&stdout)
+	c, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx.SetContext(c)
+	defer func() {
+		if out := strings.TrimSuffix(stdout.String(), "\n"); out != "" {
+			output = strings.Split(out, "\n")
+		}
+	}()
+
+	phase = PhaseLex
+	tokens, err := Scan(ctx, bufio.NewReader(bytes.NewReader(data)))
+	if err != nil {
+		return nil, phase, err
+	}
+	phase = PhaseParse
+	stmts, err := Parse(ctx, tokens)
+	if err != nil {
+		return nil, phase, err
+	}
+	phase = PhaseTypecheck
+	if err = Typecheck(ctx, stmts); err != nil {
+		return nil, phase, err
+	}
+	phase = PhaseExecute
+	return nil, phase, Execute(ctx, stmts)
+}
+
+// Returns the line on which the error originated
+func errorLine(err error) int {
+	var serr SyntaxError
+	var terr TypeError
+	var rerr RuntimeError
+	switch {
+	case errors.As(err, &serr):
+		return serr.Line
+	case errors.As(err, &terr):
+		return terr.Line
+	case errors.As(err, &rerr):
+		return rerr.Line
+	}
+	return -1
+}
+
+// Returns the message the reference implementation reports for the error,
+// where glox has an equivalent
+func conformanceMessage(err error) string {
+	var (
+		undefined   UndefinedVariableError
+		notCallable TypeNotCallableError
+		arity       ArityMismatchError
+		unary       InvalidUnaryOperatorForTypeError
+		binary      InvalidBinaryOperatorForTypeError
+		target      InvalidAssignmentTargetError
+		terminal    MissingTerminalError
+		unterm      UnterminatedStringError
+		char        UnexpectedCharacterError
+	)
+	switch {
+	case errors.As(err, &undefined):
+		return fmt.Sprintf("Undefined variable '%s'.", undefined.Name)
+	case errors.As(err, &notCallable):
+		return "Can only call functions and classes."
+	case errors.As(err, &arity):
+		return fmt.Sprintf("Expected %d arguments but got %d.", arity.Arity, arity.ArgCount)
+	case errors.As(err, &unary):
+		return "Operand must be a number."
+	case errors.As(err, &binary) && binary.OperatorType == OpAdd:
+		return "Operands must be two numbers or two strings."
+	case errors.As(err, &binary):
+		return "Operands must be numbers."
+	case errors.As(err, &target):
+		return "Error at '=': Invalid assignment target."
+	case errors.As(err, &terminal) && terminal.Token.Type == TokenEOF:
+		return "Error at end: Expect expression."
+	case errors.As(err, &terminal):
+		return fmt.Sprintf("Error at '%s': Expect expression.", terminal.Token.Lexem)
+	case errors.As(err, &unterm):
+		return "Error: Unterminated string."
+	case errors.As(err, &char):
+		return "Error: Unexpected character."
+	}
+	return err.Error()
+}
+
+// Compares the result of running the file to its expectations
+func checkConformance(expect conformanceExpect, output []string, phase Phase, err error) error {
+	if fmt.Sprint(output) != fmt.Sprint(expect.output) {
+		return fmt.Errorf("expected output %q, but got %q (error: %v)", expect.output, output, err)
+	}
+	switch {
+	case len(expect.errors) > 0:
+		if err == nil || phase == PhaseExecute {
+			return fmt.Errorf("expected a static error %v, but got %v", expect.errors, err)
+		}
+		// only the first of several errors is reported
+		got := conformanceError{line: errorLine(err), message: conformanceMessage(err)}
+		for _, expected := range expect.errors {
+			if got == expected {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected a static error %v, but got %v (%v)", expect.errors, got, err)
+	case expect.runtimeError != nil:
+		// glox reports type errors before execution which lox reports at runtime
+		if err == nil || phase != PhaseExecute && phase != PhaseTypecheck {
+			return fmt.Errorf("expected a runtime error %v, but got %v", *expect.runtimeError, err)
+		}
+		got := conformanceError{line: errorLine(err), message: conformanceMessage(err)}
+		if got != *expect.runtimeError {
+			return fmt.Errorf("expected a runtime error %v, but got %v (%v)", *expect.runtimeError, got, err)
+		}
+	case err != nil:
+		return fmt.Errorf("unexpected error: %v", err)
+	}
+	return nil
+}
+
+// Returns the reason the file is skipped, if it is
+func conformanceSkip(rel string) (string, bool) {
+	for path := rel; path != "."; path = filepath.Dir(path) {
+		if reason, ok := conformanceSkips[filepath.ToSlash(path)]; ok {
+			return reason, true
+		}
+	}
+	return "", false
+}
+
+func TestConformance(t *testing.T) {
+	type tally struct{ pass, fail, skip int }
+	matrix := map[string]*tally{}
+
+	err := filepath.WalkDir(conformanceRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".lox" {
+			return err
+		}
+		rel, err := filepath.Rel(conformanceRoot, path)
+		if err != nil {
+			return err
+		}
+		dir := filepath.ToSlash(filepath.Dir(rel))
+		if matrix[dir] == nil {
+			matrix[dir] = &tally{}
+		}
+		t.Run(filepath.ToSlash(rel), func(t *testing.T) {
+			if reason, ok := conformanceSkip(rel); ok {
+				matrix[dir].skip += 1
+				t.Skip(reason)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			output, phase, err := runConformance(data)
+			if err := checkConformance(parseConformanceExpect(data), output, phase, err); err != nil {
+				matrix[dir].fail += 1
+				t.Error(err)
+				return
+			}
+			matrix[dir].pass += 1
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dirs := make([]string, 0, len(matrix))
+	for dir := range matrix {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-20s %5s %5s %5s\n", "directory", "pass", "fail", "skip")
+	for _, dir := range dirs {
+		tl := matrix[dir]
+		fmt.Fprintf(&sb, "%-20s %5d %5d %5d\n", dir, tl.pass, tl.fail, tl.skip)
+	}
+	t.Logf("conformance matrix:\n%s", sb.String())
+}
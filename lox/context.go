@@ -1,6 +1,8 @@
 package lox
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"strings"
@@ -22,11 +24,47 @@ func (p Phase) String() string {
 }
 
 type Context struct {
-	phase   Phase
-	env     *Env
-	runtime *Runtime
-	printer Printer
-	funcs   []Function
+	phase    Phase
+	env      *Env
+	runtime  *Runtime
+	printer  Printer
+	funcs    []Function
+	calls    []StackFrame
+	done     <-chan struct{}
+	budget   int
+	steps    int
+	memory   Accountant
+	roots    []string      // directories accessible to file natives
+	stdin    *bufio.Reader // input read by readLine, if permitted
+	args     []string      // arguments returned by args()
+	environ  func(string) (string, bool)
+	bindings *Bindings // bindings established by Resolve
+	hook     Hook      // notified as execution proceeds, if set
+	tracer   Tracer    // receives execution events, if set
+}
+
+// Receives notifications as a program executes, allowing it to be inspected
+// or paused, as by a debugger. Execution aborts with any error returned.
+type Hook interface {
+	// Called before each statement is executed
+	BeforeStatement(ctx *Context, stmt Statement) error
+	// Called once a statement has executed, with any error it yielded
+	AfterStatement(ctx *Context, stmt Statement, err error)
+	// Called on entering a user function, once its arguments are bound
+	EnterFunction(ctx *Context, name string, args []Value) error
+	// Called on returning from a user function with its result
+	ExitFunction(ctx *Context, name string, val Value, err error)
+}
+
+// A Hook which is also notified of the branches taken by conditional
+// statements and by the short-circuiting logical operators
+type BranchHook interface {
+	Hook
+	// Called as a branch is taken at pos, which is that of the if keyword or
+	// of the right operand of the logical operator. Branch 0 is the then
+	// branch or the evaluation of the right operand, and branch 1 the else
+	// branch or a short circuit.
+	Branch(ctx *Context, pos Position, branch int)
 }
 
 func NewContext(w io.Writer) *Context {
@@ -36,6 +74,7 @@ func NewContext(w io.Writer) *Context {
 		runtime: NewRuntime(w),
 		printer: &defaultPrinter,
 		funcs:   make([]Function, 0),
+		calls:   make([]StackFrame, 0),
 	}
 }
 
@@ -56,6 +95,116 @@ func (ctx *Context) PushEnv(name string) (pop func()) {
 	return pop
 }
 
+// Sets the go context whose cancellation interrupts execution
+func (ctx *Context) SetContext(c context.Context) {
+	ctx.done = c.Done()
+}
+
+// Sets the maximum number of steps (loop iterations and function calls)
+// a single execution may take. Zero means no limit.
+func (ctx *Context) SetStepBudget(n int) {
+	ctx.budget = n
+}
+
+// Sets the maximum number of bytes that may be allocated over the lifetime
+// of the context. Zero means no limit.
+func (ctx *Context) SetMemoryLimit(n int) {
+	ctx.memory.limit = n
+}
+
+// Permits file natives to access paths beneath the given directories.
+// No paths are accessible unless granted.
+func (ctx *Context) AllowFiles(roots ...string) error {
+	for _, root := range roots {
+		resolved, err := resolvePath(root)
+		if err != nil {
+			return err
+		}
+		ctx.roots = append(ctx.roots, resolved)
+	}
+	return nil
+}
+
+// Sets the input read by readLine. Input is unavailable unless set.
+func (ctx *Context) SetStdin(r io.Reader) {
+	ctx.stdin = bufio.NewReader(r)
+}
+
+// Sets the arguments returned by args()
+func (ctx *Context) SetArgs(args ...string) {
+	ctx.args = args
+}
+
+// Sets the lookup used by getenv, such as os.LookupEnv.
+// The environment is inaccessible unless set.
+func (ctx *Context) SetEnviron(lookup func(string) (string, bool)) {
+	ctx.environ = lookup
+}
+
+// Sets the hook notified as statements execute and functions are entered
+// and exited
+func (ctx *Context) SetHook(h Hook) {
+	ctx.hook = h
+}
+
+func (ctx *Context) branch(pos Position, branch int) {
+	if h, ok := ctx.hook.(BranchHook); ok {
+		h.Branch(ctx, pos, branch)
+	}
+}
+
+// Returns the bindings established by the last call to Resolve, if any
+func (ctx *Context) Bindings() *Bindings {
+	return ctx.bindings
+}
+
+// Returns the accountant tracking allocations made within the context
+func (ctx *Context) Memory() *Accountant {
+	return &ctx.memory
+}
+
+// Accounts for a single step of execution, failing if execution has been
+// cancelled or the step budget has been exhausted
+func (ctx *Context) step() error {
+	select {
+	case <-ctx.done:
+		return ErrCancelled
+	default:
+	}
+	ctx.steps += 1
+	if ctx.budget > 0 && ctx.steps > ctx.budget {
+		return ErrBudgetExceeded
+	}
+	return nil
+}
+
+func (ctx *Context) PushCall(name string, pos Position) (pop func()) {
+	ctx.calls = append(ctx.calls, StackFrame{Function: name, Position: pos})
+	depth := len(ctx.calls)
+	pop = func() {
+		ctx.calls = ctx.calls[:depth-1]
+	}
+	return pop
+}
+
+// Returns the innermost active call, if any
+func (ctx *Context) Caller() (StackFrame, bool) {
+	if len(ctx.calls) == 0 {
+		return StackFrame{Position: ErrPosition}, false
+	}
+	return ctx.calls[len(ctx.calls)-1], true
+}
+
+// Returns the active calls, outermost first
+func (ctx *Context) Calls() []StackFrame {
+	return append([]StackFrame(nil), ctx.calls...)
+}
+
+// Returns the innermost environment
+func (ctx *Context) Env() *Env {
+	return ctx.env
+}
+
 func (ctx *Context) StartPhase(phase Phase) (restore func()) {
 	p := ctx.phase
 	restore = func() {
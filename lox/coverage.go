@@ -0,0 +1,150 @@
+package lox
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Records which statements of a program execute and which branches of its
+// conditionals and logical operators are taken. Install it with WithHook or
+// Context.SetHook.
+type Coverage struct {
+	path       string
+	statements map[Position]int // executions of each statement
+	branches   map[Position]*coverageBranch
+}
+
+// A conditional statement or logical operator, and the number of times
+// each of its two branches was taken (see BranchHook)
+type coverageBranch struct {
+	line  int
+	block int // index of the branching construct among those on its line
+	taken [2]int
+}
+
+// Prepares to record coverage of the program read from r, which is reported
+// as the file at path
+func NewCoverage(path string, r io.Reader) (*Coverage, error) {
+	ctx := NewContext(io.Discard)
+	tokens, err := Scan(ctx, bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+	stmts, err := Parse(ctx, tokens)
+	if err != nil {
+		return nil, err
+	}
+	c := &Coverage{
+		path:       path,
+		statements: make(map[Position]int),
+		branches:   make(map[Position]*coverageBranch),
+	}
+	walkStatements(stmts, func(node any) {
+		switch n := node.(type) {
+		case *ConditionalStatement:
+			c.branches[n.pos] = &coverageBranch{line: n.pos.Line}
+		case *BinaryExpression:
+			if n.op.Type == OpAnd || n.op.Type == OpOr {
+				pos := n.right.Position()
+				c.branches[pos] = &coverageBranch{line: pos.Line}
+			}
+		}
+		if stmt, ok := node.(Statement); ok && counted(stmt) {
+			c.statements[stmt.Position()] = 0
+		}
+	})
+	blocks := map[int]int{}
+	for _, pos := range sortedPositions(c.branches) {
+		branch := c.branches[pos]
+		branch.block = blocks[branch.line]
+		blocks[branch.line] += 1
+	}
+	return c, nil
+}
+
+func (c *Coverage) BeforeStatement(ctx *Context, stmt Statement) error {
+	if _, ok := c.statements[stmt.Position()]; ok {
+		c.statements[stmt.Position()] += 1
+	}
+	return nil
+}
+
+func (c *Coverage) AfterStatement(ctx *Context, stmt Statement, err error) {
+}
+
+func (c *Coverage) EnterFunction(ctx *Context, name string, args []Value) error {
+	return nil
+}
+
+func (c *Coverage) ExitFunction(ctx *Context, name string, val Value, err error) {
+}
+
+func (c *Coverage) Branch(ctx *Context, pos Position, branch int) {
+	if b, ok := c.branches[pos]; ok {
+		b.taken[branch] += 1
+	}
+}
+
+// Returns the number of executions of the statements starting on each line,
+// being that of the most executed statement where there are several
+func (c *Coverage) Lines() map[int]int {
+	lines := make(map[int]int)
+	for pos, hits := range c.statements {
+		lines[pos.Line] = max(lines[pos.Line], hits)
+	}
+	return lines
+}
+
+// Writes the coverage as an lcov tracefile
+func (c *Coverage) WriteLCOV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "TN:\nSF:%s\n", c.path)
+
+	found, hit := 0, 0
+	for _, pos := range sortedPositions(c.branches) {
+		branch := c.branches[pos]
+		reached := branch.taken[0]+branch.taken[1] > 0
+		for i, taken := range branch.taken {
+			// branches of a construct which never executed are reported as "-"
+			count := "-"
+			if reached {
+				count = fmt.Sprint(taken)
+			}
+			fmt.Fprintf(bw, "BRDA:%d,%d,%d,%s\n", branch.line, branch.block, i, count)
+			found += 1
+			if taken > 0 {
+				hit += 1
+			}
+		}
+	}
+	fmt.Fprintf(bw, "BRF:%d\nBRH:%d\n", found, hit)
+
+	lines := c.Lines()
+	numbers := make([]int, 0, len(lines))
+	for line := range lines {
+		numbers = append(numbers, line)
+	}
+	sort.Ints(numbers)
+	hit = 0
+	for _, line := range numbers {
+		fmt.Fprintf(bw, "DA:%d,%d\n", line, lines[line])
+		if lines[line] > 0 {
+			hit += 1
+		}
+	}
+	fmt.Fprintf(bw, "LF:%d\nLH:%d\nend_of_record\n", len(numbers), hit)
+	return bw.Flush()
+}
+
+func sortedPositions[T any](m map[Position]T) []Position {
+	positions := make([]Position, 0, len(m))
+	for pos := range m {
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].Before(positions[j])
+	})
+	return positions
+}
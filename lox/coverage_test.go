@@ -0,0 +1,97 @@
+package lox
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func runCoverage(t *testing.T, text string) *Coverage {
+	cov, err := NewCoverage("test.lox", strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	interp, err := NewInterpreter(WithStdout(&PrintSpy{}), WithHook(cov))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := interp.Eval(text); err != nil {
+		t.Fatalf("Unexpected error in %q: %s", text, err)
+	}
+	return cov
+}
+
+func TestCoverageLines(t *testing.T) {
+	tests := []struct {
+		text  string
+		lines map[int]int
+	}{
+		{text: "print 1;\nprint 2;", lines: map[int]int{1: 1, 2: 1}},
+		{text: "fun f() {\n\tprint 1;\n}", lines: map[int]int{1: 1, 2: 0}},
+		{text: "fun f() {\n\tprint 1;\n}\nf();\nf();", lines: map[int]int{1: 1, 2: 2, 4: 1, 5: 1}},
+		{text: "for (var i = 0; i < 3; i = i + 1) {\n\tprint i;\n}", lines: map[int]int{1: 1, 2: 3}},
+		{text: "if (false) {\n\tprint 1;\n} else {\n\tprint 2;\n}", lines: map[int]int{1: 1, 2: 0, 4: 1}},
+		// the most executed statement on a line counts
+		{text: "var i = 0;\nwhile (i < 2) i = i + 1;", lines: map[int]int{1: 1, 2: 2}},
+	}
+	for _, test := range tests {
+		if lines := runCoverage(t, test.text).Lines(); !reflect.DeepEqual(lines, test.lines) {
+			t.Errorf("Expected %q to cover lines %v, but got %v", test.text, test.lines, lines)
+		}
+	}
+}
+
+func TestCoverageLCOV(t *testing.T) {
+	text := `fun sign(n) {
+	if (n < 0) return -1;
+	if (n == 0 or n != n) return 0;
+	return 1;
+}
+print sign(5);
+print sign(0);
+var f = false and true;
+`
+	expected := `TN:
+SF:test.lox
+BRDA:2,0,0,0
+BRDA:2,0,1,2
+BRDA:3,0,0,1
+BRDA:3,0,1,1
+BRDA:3,1,0,1
+BRDA:3,1,1,1
+BRDA:8,0,0,0
+BRDA:8,0,1,1
+BRF:8
+BRH:6
+DA:1,1
+DA:2,2
+DA:3,2
+DA:4,1
+DA:6,1
+DA:7,1
+DA:8,1
+LF:7
+LH:7
+end_of_record
+`
+	var sb strings.Builder
+	if err := runCoverage(t, text).WriteLCOV(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != expected {
+		t.Errorf("Expected report:\n%s\nbut got:\n%s", expected, sb.String())
+	}
+}
+
+func TestCoverageUnreached(t *testing.T) {
+	cov := runCoverage(t, "fun f(a) {\n\treturn a or false;\n}")
+	var sb strings.Builder
+	if err := cov.WriteLCOV(&sb); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"BRDA:2,0,0,-\n", "BRDA:2,0,1,-\n", "BRH:0\n", "DA:2,0\n", "LH:1\n"} {
+		if !strings.Contains(sb.String(), line) {
+			t.Errorf("Expected report to contain %q, but got:\n%s", line, sb.String())
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package lox
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// How execution proceeds once a paused program resumes
+type StepMode int
+
+const (
+	StepInto     StepMode = iota // pause at the next statement
+	StepOver                     // pause at the next statement outside deeper calls
+	StepOut                      // pause once the current call returns
+	StepContinue                 // pause only at breakpoints
+)
+
+// Decides where a debugged program pauses from its breakpoints and how it
+// was last resumed. A new Stepper pauses before the first statement.
+type Stepper struct {
+	breakpoints map[int]bool
+	mode        StepMode
+	depth       int                // call depth at which execution last resumed
+	line        int                // line of the last statement executed
+	lineDepth   int                // call depth of the last statement executed
+	bodies      map[Statement]bool // bodies of the loops executed
+}
+
+func NewStepper() *Stepper {
+	return &Stepper{breakpoints: map[int]bool{}, mode: StepInto, bodies: map[Statement]bool{}}
+}
+
+// Sets a breakpoint on the statements starting on line
+func (s *Stepper) SetBreakpoint(line int) {
+	s.breakpoints[line] = true
+}
+
+// Removes the breakpoint on line, if any
+func (s *Stepper) ClearBreakpoint(line int) {
+	delete(s.breakpoints, line)
+}
+
+// Removes every breakpoint
+func (s *Stepper) ClearBreakpoints() {
+	s.breakpoints = map[int]bool{}
+}
+
+// Returns the lines with breakpoints, in ascending order
+func (s *Stepper) Breakpoints() []int {
+	lines := make([]int, 0, len(s.breakpoints))
+	for line := range s.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// Reports whether execution pauses before the statement, and if so whether
+// because of a breakpoint rather than a step
+func (s *Stepper) Pause(ctx *Context, stmt Statement) (pause, breakpoint bool) {
+	pos, depth := stmt.Position(), len(ctx.calls)
+	switch loop := stmt.(type) {
+	case *WhileStatement:
+		s.bodies[loop.body] = true
+	case *ForStatement:
+		s.bodies[loop.body] = true
+	}
+	// control returns to the loop header before each iteration, so a body
+	// on the same line as its header pauses every time
+	if s.bodies[stmt] {
+		s.line = 0
+	}
+	// blocks pause at their first statement, so that each iteration of a
+	// loop pauses, and implicit returns do not pause at all
+	if _, ok := stmt.(*BlockStatement); ok {
+		s.line = 0
+		return false, false
+	} else if pos == (Position{}) {
+		return false, false
+	}
+	// statements following another on the same line do not pause
+	same := pos.Line == s.line && depth == s.lineDepth
+	s.line, s.lineDepth = pos.Line, depth
+	if same {
+		return false, false
+	}
+	switch s.mode {
+	case StepInto:
+		return true, false
+	case StepOver:
+		if depth <= s.depth {
+			return true, false
+		}
+	case StepOut:
+		if depth < s.depth {
+			return true, false
+		}
+	}
+	return s.breakpoints[pos.Line], s.breakpoints[pos.Line]
+}
+
+// Resumes execution from the current call as directed by mode
+func (s *Stepper) Resume(ctx *Context, mode StepMode) {
+	s.mode, s.depth = mode, len(ctx.calls)
+}
+
+// Pauses execution at the next statement
+func (s *Stepper) Interrupt() {
+	s.mode = StepInto
+}
+
+// Reports whether the returning function completes a step out of it
+func (s *Stepper) SteppedOut(ctx *Context) bool {
+	return s.mode == StepOut && len(ctx.calls) == s.depth
+}
+
+// Evaluates the expression in src in the current environment without
+// notifying the hook, as when inspecting a paused program
+func (ctx *Context) EvaluateSource(src string) (Value, error) {
+	if !strings.HasSuffix(src, ";") {
+		src += ";"
+	}
+	tokens, err := Scan(ctx, strings.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	stmts, err := Parse(ctx, tokens)
+	if err != nil {
+		return nil, err
+	}
+	var expr *ExpressionStatement
+	if len(stmts) == 1 {
+		expr, _ = stmts[0].(*ExpressionStatement)
+	}
+	if expr == nil {
+		return nil, fmt.Errorf("not an expression: %s", src)
+	}
+	hook := ctx.hook
+	ctx.hook = nil
+	defer func() {
+		ctx.hook = hook
+	}()
+	return expr.expr.Evaluate(ctx)
+}
+
+const debuggerHelp = `commands:
+	break N, b N    set a breakpoint on line N
+	clear N         remove the breakpoint on line N
+	breakpoints     list the breakpoints
+	continue, c     run until the next breakpoint
+	step, s         step into the next statement
+	next, n         step over calls to the next statement
+	out, o          run until the current function returns
+	env             show the variables of the paused frame
+	print, p EXPR   evaluate EXPR in the paused frame
+	bt              show the active calls
+	list, l         show the source around the paused statement
+	help, h         show this help
+	quit, q         stop the program
+an empty line repeats the last command
+`
+
+// An interactive debugger reading commands from in and writing to out.
+// Install it with WithHook or Context.SetHook. It pauses before the first
+// statement and thereafter as directed by the commands.
+type Debugger struct {
+	*Stepper
+	in       *bufio.Scanner
+	out      io.Writer
+	source   []string
+	last     string // last command entered
+	detached bool   // whether input is exhausted
+}
+
+func NewDebugger(in io.Reader, out io.Writer) *Debugger {
+	return &Debugger{
+		Stepper: NewStepper(),
+		in:      bufio.NewScanner(in),
+		out:     out,
+	}
+}
+
+// Sets the source shown by the list command
+func (d *Debugger) SetSource(src string) {
+	d.source = strings.Split(strings.TrimSuffix(src, "\n"), "\n")
+}
+
+func (d *Debugger) BeforeStatement(ctx *Context, stmt Statement) error {
+	pause, breakpoint := d.Pause(ctx, stmt)
+	if d.detached || !pause {
+		return nil
+	}
+	if breakpoint {
+		fmt.Fprintf(d.out, "breakpoint at line %d\n", stmt.Position().Line)
+	}
+	return d.pause(ctx, stmt)
+}
+
+func (d *Debugger) AfterStatement(ctx *Context, stmt Statement, err error) {
+}
+
+func (d *Debugger) EnterFunction(ctx *Context, name string, args []Value) error {
+	return nil
+}
+
+func (d *Debugger) ExitFunction(ctx *Context, name string, val Value, err error) {
+	if d.detached || err != nil || !d.SteppedOut(ctx) {
+		return
+	}
+	fmt.Fprintf(d.out, "%s returned %s\n", name, d.format(val))
+}
+
+// Reads and runs commands until one resumes execution
+func (d *Debugger) pause(ctx *Context, stmt Statement) error {
+	d.show(stmt.Position().Line, stmt)
+	for {
+		fmt.Fprint(d.out, "(debug) ")
+		if !d.in.Scan() {
+			fmt.Fprintln(d.out)
+			d.detached = true
+			return d.in.Err()
+		}
+		cmd := strings.TrimSpace(d.in.Text())
+		if cmd == "" {
+			cmd = d.last
+		}
+		d.last = cmd
+		mode, resume, err := d.command(ctx, stmt, cmd)
+		if err != nil {
+			return err
+		} else if resume {
+			d.Resume(ctx, mode)
+			return nil
+		}
+	}
+}
+
+// Runs a command, reporting whether and how it resumes execution
+func (d *Debugger) command(ctx *Context, stmt Statement, cmd string) (mode StepMode, resume bool, err error) {
+	name, arg, _ := strings.Cut(cmd, " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case "":
+	case "continue", "c":
+		return StepContinue, true, nil
+	case "step", "s":
+		return StepInto, true, nil
+	case "next", "n":
+		return StepOver, true, nil
+	case "out", "o":
+		return StepOut, true, nil
+	case "break", "b", "clear":
+		line, err := strconv.Atoi(arg)
+		if err != nil || line < 1 {
+			fmt.Fprintf(d.out, "invalid line %q\n", arg)
+			break
+		}
+		if name == "clear" {
+			d.ClearBreakpoint(line)
+			fmt.Fprintf(d.out, "cleared breakpoint on line %d\n", line)
+		} else {
+			d.SetBreakpoint(line)
+			fmt.Fprintf(d.out, "breakpoint on line %d\n", line)
+		}
+	case "breakpoints":
+		for _, line := range d.Breakpoints() {
+			fmt.Fprintf(d.out, "line %d\n", line)
+		}
+	case "env":
+		for env := ctx.env; env != nil; env = env.parent {
+			fmt.Fprintln(d.out, env.String())
+		}
+	case "print", "p":
+		val, err := ctx.EvaluateSource(arg)
+		if err != nil {
+			fmt.Fprintf(d.out, "error: %s\n", err)
+			break
+		}
+		fmt.Fprintln(d.out, d.format(val))
+	case "bt":
+		fmt.Fprintf(d.out, "#0 line %d\n", stmt.Position().Line)
+		for i := len(ctx.calls) - 1; i >= 0; i-- {
+			frame := ctx.calls[i]
+			fmt.Fprintf(d.out, "#%d %s called at %s\n", len(ctx.calls)-i, frame.Function, frame.Position)
+		}
+	case "list", "l":
+		d.list(stmt.Position().Line)
+	case "help", "h":
+		fmt.Fprint(d.out, debuggerHelp)
+	case "quit", "q":
+		return mode, false, NewExitError(ExitCodeOK)
+	default:
+		fmt.Fprintf(d.out, "unknown command %q (try help)\n", name)
+	}
+	return mode, false, nil
+}
+
+func (d *Debugger) format(val Value) string {
+	if val == nil {
+		return "nil"
+	}
+	str, err := val.Print(&defaultPrinter)
+	if err != nil {
+		return val.String()
+	}
+	return str
+}
+
+// Shows the statement about to execute
+func (d *Debugger) show(line int, stmt Statement) {
+	if line > 0 && line <= len(d.source) {
+		fmt.Fprintf(d.out, "%d:\t%s\n", line, strings.TrimSpace(d.source[line-1]))
+		return
+	}
+	fmt.Fprintf(d.out, "%d:\t%s\n", line, stmt)
+}
+
+// Shows the source surrounding line
+func (d *Debugger) list(line int) {
+	if len(d.source) == 0 {
+		fmt.Fprintln(d.out, "no source available")
+		return
+	}
+	start, end := max(line-3, 1), min(line+3, len(d.source))
+	for n := start; n <= end; n++ {
+		marker := " "
+		if n == line {
+			marker = ">"
+		} else if d.breakpoints[n] {
+			marker = "*"
+		}
+		fmt.Fprintf(d.out, "%s %3d\t%s\n", marker, n, d.source[n-1])
+	}
+}
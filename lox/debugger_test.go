@@ -0,0 +1,138 @@
+package lox
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const debuggerSource = `fun add(a, b) {
+	var c = a + b;
+	return c;
+}
+var x = 1;
+var y = add(x, 2);
+for (var i = 0; i < 2; i = i + 1) {
+	x = x + i;
+}
+print y;
+`
+
+var debuggerStopRe = regexp.MustCompile(`(?m)(\d+):\t`)
+
+// Runs the source under a debugger given the commands, returning its output
+// and the lines at which it paused
+func runDebugger(t *testing.T, commands []string, breakpoints ...int) (string, []int, error) {
+	return runDebuggerSource(t, debuggerSource, commands, breakpoints...)
+}
+
+func runDebuggerSource(t *testing.T, src string, commands []string, breakpoints ...int) (string, []int, error) {
+	var out strings.Builder
+	debugger := NewDebugger(strings.NewReader(strings.Join(commands, "\n")), &out)
+	debugger.SetSource(src)
+	for _, line := range breakpoints {
+		debugger.SetBreakpoint(line)
+	}
+	interp, err := NewInterpreter(WithStdout(&PrintSpy{}), WithHook(debugger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = interp.Eval(src)
+	var stops []int
+	for _, m := range debuggerStopRe.FindAllStringSubmatch(out.String(), -1) {
+		line, _ := strconv.Atoi(m[1])
+		stops = append(stops, line)
+	}
+	return out.String(), stops, err
+}
+
+func TestDebuggerStepping(t *testing.T) {
+	tests := []struct {
+		commands    []string
+		breakpoints []int
+		stops       []int
+	}{
+		{commands: []string{"c"}, stops: []int{1}},
+		{commands: []string{"n", "n", "n", "n", "n", "n", "n"}, stops: []int{1, 5, 6, 7, 8, 8, 10}},
+		{commands: []string{"n", "n", "s", "s", "s", "s"}, stops: []int{1, 5, 6, 2, 3, 7, 8}},
+		{commands: []string{"n", "n", "s", "o", "c"}, stops: []int{1, 5, 6, 2, 7}},
+		{commands: []string{"n", "", "", "c"}, stops: []int{1, 5, 6, 7}},
+		{commands: []string{"c", "c", "c"}, breakpoints: []int{3, 8}, stops: []int{1, 3, 8, 8}},
+		{commands: []string{"b 10", "c", "c"}, stops: []int{1, 10}},
+		{commands: []string{"c"}, breakpoints: []int{2}, stops: []int{1, 2}},
+	}
+	for _, test := range tests {
+		_, stops, err := runDebugger(t, test.commands, test.breakpoints...)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %s", test.commands, err)
+			continue
+		}
+		if !reflect.DeepEqual(stops, test.stops) {
+			t.Errorf("Expected %q to stop on lines %v, but stopped on %v", test.commands, test.stops, stops)
+		}
+	}
+}
+
+func TestDebuggerSingleLineLoops(t *testing.T) {
+	src := "for (var i = 0; i < 3; i = i + 1) print i;\nvar j = 0;\nwhile (j < 2) j = j + 1;\nprint j;\n"
+	tests := []struct {
+		commands    []string
+		breakpoints []int
+		stops       []int
+	}{
+		{commands: []string{"n", "n", "n", "n", "n", "n", "n", "n", "n", "n"}, stops: []int{1, 1, 1, 1, 2, 3, 3, 3, 4}},
+		{commands: []string{"c", "c", "c", "c", "c"}, breakpoints: []int{1}, stops: []int{1, 1, 1, 1}},
+		{commands: []string{"c", "c", "c", "c"}, breakpoints: []int{3}, stops: []int{1, 3, 3, 3}},
+	}
+	for _, test := range tests {
+		_, stops, err := runDebuggerSource(t, src, test.commands, test.breakpoints...)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %s", test.commands, err)
+			continue
+		}
+		if !reflect.DeepEqual(stops, test.stops) {
+			t.Errorf("Expected %q to stop on lines %v, but stopped on %v", test.commands, test.stops, stops)
+		}
+	}
+}
+
+func TestDebuggerInspection(t *testing.T) {
+	tests := []struct {
+		commands    []string
+		breakpoints []int
+		output      string
+	}{
+		{commands: []string{"c", "p a * 10"}, breakpoints: []int{2}, output: "(debug) 10\n"},
+		{commands: []string{"c", "print add(x, 5)"}, breakpoints: []int{6}, output: "(debug) 6\n"},
+		{commands: []string{"c", "p c"}, breakpoints: []int{3}, output: "(debug) 3\n"},
+		{commands: []string{"c", "env"}, breakpoints: []int{3}, output: "(debug) Env(root:add){values:a=1,b=2,c=3"},
+		{commands: []string{"c", "bt"}, breakpoints: []int{3}, output: "(debug) #0 line 3\n#1 add called at (6,12)\n"},
+		{commands: []string{"c", "s", "s", "o"}, breakpoints: []int{6}, output: "(debug) add returned 3\n"},
+		{commands: []string{"c", "p nope"}, breakpoints: []int{5}, output: "(debug) error: "},
+		{commands: []string{"c", "l"}, breakpoints: []int{5, 6}, output: "    4\t}\n>   5\tvar x = 1;\n*   6\t"},
+		{commands: []string{"frobnicate", "p 1; 2"}, output: "unknown command \"frobnicate\" (try help)\n(debug) error: not an expression"},
+	}
+	for _, test := range tests {
+		out, _, err := runDebugger(t, test.commands, test.breakpoints...)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %s", test.commands, err)
+			continue
+		}
+		if !strings.Contains(out, test.output) {
+			t.Errorf("Expected %q to output %q, but got:\n%s", test.commands, test.output, out)
+		}
+	}
+}
+
+func TestDebuggerQuit(t *testing.T) {
+	_, stops, err := runDebugger(t, []string{"n", "q", "c"})
+	if !errors.Is(err, NewExitError(ExitCodeOK)) {
+		t.Errorf("Expected quitting to yield an exit error, but got %v", err)
+	}
+	if !reflect.DeepEqual(stops, []int{1, 5}) {
+		t.Errorf("Expected to stop on lines [1 5], but stopped on %v", stops)
+	}
+}
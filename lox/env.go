@@ -2,6 +2,7 @@ package lox
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -34,6 +35,21 @@ func (e *Env) Name() string {
 	return strings.Join(e.nesting, ":")
 }
 
+// Returns the enclosing environment, or nil for the root
+func (e *Env) Parent() *Env {
+	return e.parent
+}
+
+// Returns the names of the values bound in the environment, in order
+func (e *Env) Names() []string {
+	names := make([]string, 0, len(e.values))
+	for name := range e.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (e *Env) String() string {
 	values := make([]string, len(e.values))
 	i := 0
@@ -47,6 +63,8 @@ func (e *Env) String() string {
 		types[i] = fmt.Sprintf("%s=%s", key, val)
 		i += 1
 	}
+	sort.Strings(values)
+	sort.Strings(types)
 	return fmt.Sprintf("Env(%s){values:%s, types:%s}", e.Name(), strings.Join(values, ","), strings.Join(types, ", "))
 }
 
@@ -3,10 +3,18 @@ package lox
 import (
 	"errors"
 	"fmt"
+	"io/fs"
+	"strings"
 )
 
 var ErrNotYetImplemented = errors.New("not yet implemented")
 
+// Error indicating that execution was interrupted by its go context
+var ErrCancelled = errors.New("execution cancelled")
+
+// Error indicating that execution exhausted its step budget
+var ErrBudgetExceeded = errors.New("step budget exceeded")
+
 // Container for all syntax-related errors
 type SyntaxError struct {
 	Err      error // the wrapped error
@@ -89,14 +97,16 @@ func NewUnmatchedTokenError(token Token) UnmatchedTokenError {
 }
 
 // Error indicating that an expected terminal was not found
-type MissingTerminalError struct{}
+type MissingTerminalError struct {
+	Token Token // found in place of the terminal
+}
 
 func (e MissingTerminalError) Error() string {
 	return "missing terminal"
 }
 
 func NewMissingTerminalError(token Token) MissingTerminalError {
-	return MissingTerminalError{}
+	return MissingTerminalError{Token: token}
 }
 
 // Error indicating an error in numeric conversion from token
@@ -221,26 +231,32 @@ func NewInvalidBinaryOperatorForTypeError(opType OperatorType, left, right Type)
 	}
 }
 
-// type InvalidArgumentTypeForParameter struct {
-// 	Type
-// 	Param
-// }
+// Error indicating that an argument's type is incompatible with its parameter
+type InvalidArgumentTypeError struct {
+	Function string
+	Index    int
+	Arg      Type
+	Param    Type
+}
 
-// func (e InvalidArgumentTypeForParameter) Error() string {
-// 	return fmt.Sprintf("invalid argument type %s for parameter %s of type %s", e.Type, e.Param.Name, e.Param.Type)
-// }
+func (e InvalidArgumentTypeError) Error() string {
+	return fmt.Sprintf("invalid argument %d to %s: expected %s but got %s", e.Index+1, e.Function, e.Param, e.Arg)
+}
 
-// func NewInvalidArgumentTypeForParameter(typ Type, param Param) InvalidArgumentTypeForParameter {
-// 	return InvalidArgumentTypeForParameter{
-// 		Type:  typ,
-// 		Param: param,
-// 	}
-// }
+func NewInvalidArgumentTypeError(function string, index int, arg, param Type) InvalidArgumentTypeError {
+	return InvalidArgumentTypeError{
+		Function: function,
+		Index:    index,
+		Arg:      arg,
+		Param:    param,
+	}
+}
 
 // Container for all runtime errors
 type RuntimeError struct {
-	Err      error // the wrapped error
-	Position       // the originating location
+	Err      error       // the wrapped error
+	Position             // the originating location
+	Trace    *StackTrace // the call stack unwound by the error, if any
 }
 
 func (e RuntimeError) Error() string {
@@ -251,6 +267,17 @@ func (e RuntimeError) Unwrap() error {
 	return e.Err
 }
 
+// Returns a copy of the error with the frame appended to its trace
+func (e RuntimeError) WithFrame(frame StackFrame) RuntimeError {
+	trace := &StackTrace{}
+	if e.Trace != nil {
+		trace.Frames = append(trace.Frames, e.Trace.Frames...)
+	}
+	trace.Frames = append(trace.Frames, frame)
+	e.Trace = trace
+	return e
+}
+
 func NewRuntimeError(err error, pos Position) RuntimeError {
 	return RuntimeError{
 		Err:      err,
@@ -345,6 +372,33 @@ func NewUndefinedFunctionError(name string) UndefinedFunctionError {
 	return UndefinedFunctionError{Name: name}
 }
 
+// Error indicating that the value has no property with the given name
+type UndefinedPropertyError struct {
+	Name string
+	Type
+}
+
+func (e UndefinedPropertyError) Error() string {
+	return fmt.Sprintf("property %s is not defined on type %s", e.Name, e.Type)
+}
+
+func NewUndefinedPropertyError(name string, typ Type) UndefinedPropertyError {
+	return UndefinedPropertyError{Name: name, Type: typ}
+}
+
+// Error indicating that values of the type have no properties
+type TypeHasNoPropertiesError struct {
+	Type
+}
+
+func (e TypeHasNoPropertiesError) Error() string {
+	return fmt.Sprintf("type %s has no properties", e.Type)
+}
+
+func NewTypeHasNoPropertiesError(typ Type) TypeHasNoPropertiesError {
+	return TypeHasNoPropertiesError{Type: typ}
+}
+
 // Error indicating division by zero
 type DivideByZeroError struct {
 	Numerator   ValueNumeric
@@ -370,3 +424,361 @@ func (e VariableRedeclarationError) Error() string {
 func NewVariableRedeclarationError(name string) VariableRedeclarationError {
 	return VariableRedeclarationError{Name: name}
 }
+
+// A single function call in a lox-level stack trace
+type StackFrame struct {
+	Function string // name of the called function
+	Position        // location of the call site
+}
+
+// The function calls unwound by a runtime error, innermost first
+type StackTrace struct {
+	Frames []StackFrame
+}
+
+// Returns a python-style traceback (most recent call last) for the
+// runtime error wrapped by err, or an empty string if it has no trace
+func Traceback(err error) string {
+	var rerr RuntimeError
+	if !errors.As(err, &rerr) || rerr.Trace == nil || len(rerr.Trace.Frames) == 0 {
+		return ""
+	}
+	frames := rerr.Trace.Frames
+	var sb strings.Builder
+	sb.WriteString("Traceback (most recent call last):\n")
+	fmt.Fprintf(&sb, "  %s, in <script>\n", tracebackLocation(frames[len(frames)-1].Position))
+	for i := len(frames) - 1; i > 0; i-- {
+		fmt.Fprintf(&sb, "  %s, in %s\n", tracebackLocation(frames[i-1].Position), frames[i].Function)
+	}
+	fmt.Fprintf(&sb, "  %s, in %s\n", tracebackLocation(rerr.Position), frames[0].Function)
+	return sb.String()
+}
+
+func tracebackLocation(pos Position) string {
+	if pos.Invalid() {
+		return "<host>"
+	}
+	return fmt.Sprintf("line %d", pos.Line)
+}
+
+// Error indicating that a configured resource limit was exceeded
+type ResourceLimitError struct {
+	Resource string
+	Limit    int
+	Used     int
+}
+
+func (e ResourceLimitError) Error() string {
+	return fmt.Sprintf("%s limit of %d exceeded (%d used)", e.Resource, e.Limit, e.Used)
+}
+
+func NewResourceLimitError(resource string, limit, used int) ResourceLimitError {
+	return ResourceLimitError{Resource: resource, Limit: limit, Used: used}
+}
+
+// Error indicating that the result of a function would be too large to
+// represent
+type ResultTooLargeError struct {
+	Function string
+}
+
+func (e ResultTooLargeError) Error() string {
+	return fmt.Sprintf("result of %s is too large", e.Function)
+}
+
+func NewResultTooLargeError(function string) ResultTooLargeError {
+	return ResultTooLargeError{Function: function}
+}
+
+// Error indicating that a value could not be converted between go and lox
+type ConversionError struct {
+	From string
+	To   string
+}
+
+func (e ConversionError) Error() string {
+	return fmt.Sprintf("cannot convert %s to %s", e.From, e.To)
+}
+
+func NewConversionError(from, to string) ConversionError {
+	return ConversionError{From: from, To: to}
+}
+
+// Error indicating that a numeric argument must be an integer
+type NonIntegerArgumentError struct {
+	Function string
+	Index    int
+	Arg      Value
+}
+
+func (e NonIntegerArgumentError) Error() string {
+	return fmt.Sprintf("invalid argument %d to %s: expected an integer but got %s", e.Index+1, e.Function, e.Arg)
+}
+
+func NewNonIntegerArgumentError(function string, index int, arg Value) NonIntegerArgumentError {
+	return NonIntegerArgumentError{Function: function, Index: index, Arg: arg}
+}
+
+// Error indicating that an integer argument cannot be represented as an int64
+type IntegerOutOfRangeError struct {
+	Function string
+	Index    int
+	Arg      Value
+}
+
+func (e IntegerOutOfRangeError) Error() string {
+	return fmt.Sprintf("invalid argument %d to %s: %s is out of the integer range", e.Index+1, e.Function, e.Arg)
+}
+
+func NewIntegerOutOfRangeError(function string, index int, arg Value) IntegerOutOfRangeError {
+	return IntegerOutOfRangeError{Function: function, Index: index, Arg: arg}
+}
+
+// Error indicating that the bounds of a range are reversed, or too far
+// apart
+type InvalidRangeError struct {
+	Low  float64
+	High float64
+}
+
+func (e InvalidRangeError) Error() string {
+	return fmt.Sprintf("invalid range [%v, %v]", e.Low, e.High)
+}
+
+func NewInvalidRangeError(low, high float64) InvalidRangeError {
+	return InvalidRangeError{Low: low, High: high}
+}
+
+// Error indicating that an index lies outside of a sequence
+type IndexOutOfRangeError struct {
+	Index  int
+	Length int
+}
+
+func (e IndexOutOfRangeError) Error() string {
+	return fmt.Sprintf("index %d out of range for length %d", e.Index, e.Length)
+}
+
+func NewIndexOutOfRangeError(index, length int) IndexOutOfRangeError {
+	return IndexOutOfRangeError{Index: index, Length: length}
+}
+
+// Error indicating that a string does not represent a number
+type InvalidNumberError struct {
+	Str string
+}
+
+func (e InvalidNumberError) Error() string {
+	return fmt.Sprintf("cannot parse %q as a number", e.Str)
+}
+
+func NewInvalidNumberError(str string) InvalidNumberError {
+	return InvalidNumberError{Str: str}
+}
+
+// Error indicating that a string does not consist of a single character
+type NotACharacterError struct {
+	Str string
+}
+
+func (e NotACharacterError) Error() string {
+	return fmt.Sprintf("%q is not a single character", e.Str)
+}
+
+func NewNotACharacterError(str string) NotACharacterError {
+	return NotACharacterError{Str: str}
+}
+
+// Error indicating that a native was denied access to a path
+type FileAccessDeniedError struct {
+	Op   string
+	Path string
+}
+
+func (e FileAccessDeniedError) Error() string {
+	return fmt.Sprintf("%s: access to %s is not permitted", e.Op, e.Path)
+}
+
+func NewFileAccessDeniedError(op, path string) FileAccessDeniedError {
+	return FileAccessDeniedError{Op: op, Path: path}
+}
+
+// Error indicating that a file operation failed
+type FileError struct {
+	Op   string
+	Path string
+	Err  error
+}
+
+func (e FileError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Op, e.Path, e.Err)
+}
+
+func (e FileError) Unwrap() error {
+	return e.Err
+}
+
+func NewFileError(op, path string, err error) FileError {
+	var perr *fs.PathError
+	if errors.As(err, &perr) {
+		// the path is reported by the FileError itself
+		err = perr.Err
+	}
+	return FileError{Op: op, Path: path, Err: err}
+}
+
+// Error indicating malformed JSON or a value unrepresentable in JSON
+type JSONError struct {
+	Err error
+}
+
+func (e JSONError) Error() string {
+	return fmt.Sprintf("invalid json: %s", e.Err)
+}
+
+func (e JSONError) Unwrap() error {
+	return e.Err
+}
+
+func NewJSONError(err error) JSONError {
+	return JSONError{Err: err}
+}
+
+// Error indicating that a value of the type cannot be serialized
+type UnserializableValueError struct {
+	Type
+}
+
+func (e UnserializableValueError) Error() string {
+	return fmt.Sprintf("cannot serialize value of type %s", e.Type)
+}
+
+func NewUnserializableValueError(typ Type) UnserializableValueError {
+	return UnserializableValueError{Type: typ}
+}
+
+// Error indicating that a string does not match a time layout
+type InvalidTimeError struct {
+	Str    string
+	Layout string
+}
+
+func (e InvalidTimeError) Error() string {
+	return fmt.Sprintf("cannot parse %q as %s", e.Str, e.Layout)
+}
+
+func NewInvalidTimeError(str, layout string) InvalidTimeError {
+	return InvalidTimeError{Str: str, Layout: layout}
+}
+
+// Error indicating that a regular expression failed to compile
+type InvalidPatternError struct {
+	Pattern string
+	Err     error
+}
+
+func (e InvalidPatternError) Error() string {
+	return fmt.Sprintf("invalid pattern %q: %s", e.Pattern, e.Err)
+}
+
+func (e InvalidPatternError) Unwrap() error {
+	return e.Err
+}
+
+func NewInvalidPatternError(pattern string, err error) InvalidPatternError {
+	return InvalidPatternError{Pattern: pattern, Err: err}
+}
+
+// Error requesting that the process exit with the code. It unwinds
+// execution like any other error.
+type ExitError struct {
+	Code ExitCode
+}
+
+func (e ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+func NewExitError(code ExitCode) ExitError {
+	return ExitError{Code: code}
+}
+
+// Error indicating that an exit code is outside of 0..255
+type InvalidExitCodeError struct {
+	Code int64
+}
+
+func (e InvalidExitCodeError) Error() string {
+	return fmt.Sprintf("invalid exit code %d: must be between 0 and 255", e.Code)
+}
+
+func NewInvalidExitCodeError(code int64) InvalidExitCodeError {
+	return InvalidExitCodeError{Code: code}
+}
+
+// Error indicating that a native was denied access to the environment
+type EnvironmentAccessDeniedError struct {
+	Name string
+}
+
+func (e EnvironmentAccessDeniedError) Error() string {
+	return fmt.Sprintf("getenv: access to %s is not permitted", e.Name)
+}
+
+func NewEnvironmentAccessDeniedError(name string) EnvironmentAccessDeniedError {
+	return EnvironmentAccessDeniedError{Name: name}
+}
+
+// Error indicating that a native requires a non-empty list
+type EmptyListError struct {
+	Function string
+}
+
+func (e EmptyListError) Error() string {
+	return fmt.Sprintf("%s of empty list with no initial value", e.Function)
+}
+
+func NewEmptyListError(function string) EmptyListError {
+	return EmptyListError{Function: function}
+}
+
+// Error indicating that values of the types have no natural order
+type IncomparableValuesError struct {
+	Left  Type
+	Right Type
+}
+
+func (e IncomparableValuesError) Error() string {
+	return fmt.Sprintf("cannot compare %s with %s", e.Left, e.Right)
+}
+
+func NewIncomparableValuesError(left, right Type) IncomparableValuesError {
+	return IncomparableValuesError{Left: left, Right: right}
+}
+
+// Error indicating that a comparator returned a non-numeric result
+type InvalidComparatorResultError struct {
+	Type
+}
+
+func (e InvalidComparatorResultError) Error() string {
+	return fmt.Sprintf("comparator must return a number but returned %s", e.Type)
+}
+
+func NewInvalidComparatorResultError(typ Type) InvalidComparatorResultError {
+	return InvalidComparatorResultError{Type: typ}
+}
+
+// Error indicating that a serialized AST contains an unknown kind of node
+type UnknownASTNodeError struct {
+	Category string
+	Kind     string
+}
+
+func (e UnknownASTNodeError) Error() string {
+	return fmt.Sprintf("unknown %s kind %q", e.Category, e.Kind)
+}
+
+func NewUnknownASTNodeError(category, kind string) UnknownASTNodeError {
+	return UnknownASTNodeError{Category: category, Kind: kind}
+}
@@ -2,8 +2,6 @@ package lox
 
 import (
 	"errors"
-
-	"github.com/rs/zerolog/log"
 )
 
 func (e *GroupingExpression) Evaluate(ctx *Context) (Value, error) {
@@ -20,7 +18,9 @@ func (e *AssignmentExpression) Evaluate(ctx *Context) (Value, error) {
 		return nil, NewRuntimeError(NewUndefinedVariableError(e.name), e.Position())
 	}
 	env.SetValue(e.name, val)
-	log.Debug().Msgf("(evaluate) Env(%s) %s = %s (prev %s)", env.Name(), e.name, val, prev)
+	if ctx.tracer != nil {
+		ctx.trace(TraceEvent{Kind: TraceAssign, Position: e.Position(), Name: e.name, Values: []Value{val}})
+	}
 	return val, nil
 }
 
@@ -31,9 +31,24 @@ func (e *VariableExpression) Evaluate(ctx *Context) (Value, error) {
 	if fn := ctx.runtime.Function(e.name); fn != nil {
 		return &ValueCallable{name: e.name, fn: fn}, nil
 	}
+	if m := ctx.runtime.Module(e.name); m != nil {
+		return m, nil
+	}
 	return nil, NewRuntimeError(NewUndefinedVariableError(e.name), e.Position())
 }
 
+func (e *PropertyExpression) Evaluate(ctx *Context) (Value, error) {
+	obj, err := e.object.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	val, err := property(obj, e.name)
+	if err != nil {
+		return nil, NewRuntimeError(err, e.Position())
+	}
+	return val, nil
+}
+
 func (e *CallExpression) Evaluate(ctx *Context) (Value, error) {
 	var err error
 	callee, err := e.callee.Evaluate(ctx)
@@ -53,8 +68,14 @@ func (e *CallExpression) Evaluate(ctx *Context) (Value, error) {
 			return nil, err
 		}
 	}
+	pop := ctx.PushCall(call.name, e.Position())
 	val, err := call.Call(ctx, args...)
+	pop()
 	if err != nil {
+		var rerr RuntimeError
+		if !errors.As(err, &rerr) {
+			err = NewRuntimeError(err, e.Position())
+		}
 		return nil, err
 	}
 	return val, nil
@@ -136,7 +157,8 @@ func evaluateUnary(ctx *Context, e *UnaryExpression, right Value) (val Value, er
 		err = NewInvalidUnaryOperatorForTypeError(e.op.Type, right.Type())
 	}
 	if err != nil {
-		if !errors.Is(err, RuntimeError{}) {
+		var rerr RuntimeError
+		if !errors.As(err, &rerr) {
 			err = NewRuntimeError(err, e.Position())
 		}
 		return nil, err
@@ -154,12 +176,18 @@ func evaluateBinaryWithShortCircuit(ctx *Context, e *BinaryExpression) (val Valu
 	case OpAnd:
 		val, err = left, nil
 		if val.Truthy() {
+			ctx.branch(e.right.Position(), 0)
 			val, err = e.right.Evaluate(ctx)
+		} else {
+			ctx.branch(e.right.Position(), 1)
 		}
 	case OpOr:
 		val, err = left, nil
 		if !left.Truthy() {
+			ctx.branch(e.right.Position(), 0)
 			val, err = e.right.Evaluate(ctx)
+		} else {
+			ctx.branch(e.right.Position(), 1)
 		}
 	default:
 		invalid = true
@@ -168,7 +196,8 @@ func evaluateBinaryWithShortCircuit(ctx *Context, e *BinaryExpression) (val Valu
 		err = NewInvalidBinaryOperatorForTypeError(e.op.Type, left.Type(), e.right.Type())
 	}
 	if err != nil {
-		if !errors.Is(err, RuntimeError{}) {
+		var rerr RuntimeError
+		if !errors.As(err, &rerr) {
 			err = NewRuntimeError(err, e.Position())
 		}
 		return nil, err
@@ -233,7 +262,10 @@ func evaluateBinary(ctx *Context, e *BinaryExpression, left, right Value) (val V
 		}
 		switch e.op.Type {
 		case OpAdd:
-			val, err = s.Concat(right)
+			var str ValueString
+			if str, err = s.Concat(right); err == nil {
+				val, err = str, ctx.memory.Allocate(len(str))
+			}
 		default:
 			invalid = true
 		}
@@ -244,10 +276,38 @@ func evaluateBinary(ctx *Context, e *BinaryExpression, left, right Value) (val V
 		err = NewInvalidBinaryOperatorForTypeError(e.op.Type, left.Type(), right.Type())
 	}
 	if err != nil {
-		if !errors.Is(err, RuntimeError{}) {
+		var rerr RuntimeError
+		if !errors.As(err, &rerr) {
 			err = NewRuntimeError(err, e.Position())
 		}
 		return nil, err
 	}
 	return val, err
 }
+
+// Returns the named property of the value
+func property(obj Value, name string) (Value, error) {
+	switch o := obj.(type) {
+	case *Module:
+		if val := o.Member(name); val != nil {
+			return val, nil
+		}
+		return nil, NewUndefinedPropertyError(name, obj.Type())
+	case *ValueMap:
+		if val, ok := o.Get(name); ok {
+			return val, nil
+		}
+		return nil, NewUndefinedPropertyError(name, obj.Type())
+	case ValueString:
+		if fn := stringMethod(o, name); fn != nil {
+			return &ValueCallable{name: name, fn: fn}, nil
+		}
+		return nil, NewUndefinedPropertyError(name, obj.Type())
+	case ValueTime:
+		if prop, ok := timeProperties[name]; ok {
+			return ValueNumeric(prop(o.t)), nil
+		}
+		return nil, NewUndefinedPropertyError(name, obj.Type())
+	}
+	return nil, NewTypeHasNoPropertiesError(obj.Type())
+}
@@ -19,11 +19,24 @@ func NewExecutor(w io.Writer) *Executor {
 }
 
 func Execute(ctx *Context, elems []Statement) error {
+	_, err := execute(ctx, elems)
+	return err
+}
+
+// Executes the statements, yielding the value of the final statement if it
+// is an expression statement and Nil otherwise
+func execute(ctx *Context, elems []Statement) (val Value, err error) {
 	restore := ctx.StartPhase(PhaseExecute)
 	defer restore()
-	for _, elem := range elems {
-		log.Debug().Msgf("(%s) executing %s", ctx.Phase(), elem)
-		if err := elem.Execute(ctx); err != nil {
+	ctx.steps = 0
+	val = Nil
+	for i, elem := range elems {
+		if expr, ok := elem.(*ExpressionStatement); ok && i == len(elems)-1 {
+			val, err = evaluateStatement(ctx, expr)
+		} else {
+			err = executeStatement(ctx, elem)
+		}
+		if err != nil {
 			if ret, ok := err.(ReturnErr); ok {
 				err = NewRuntimeError(
 					fmt.Errorf("out of place return statement"),
@@ -31,17 +44,52 @@ func Execute(ctx *Context, elems []Statement) error {
 				)
 			}
 			log.Error().Msgf("(%s) error in %q: %s", ctx.Phase(), elem, err)
-			return err
+			return nil, err
 		}
 	}
-	return nil
+	return val, nil
+}
+
+// Executes the statement, notifying the context's tracer and hook
+func executeStatement(ctx *Context, stmt Statement) error {
+	if ctx.tracer != nil {
+		ctx.traceStatement(stmt)
+	}
+	if ctx.hook == nil {
+		return stmt.Execute(ctx)
+	}
+	if err := ctx.hook.BeforeStatement(ctx, stmt); err != nil {
+		return err
+	}
+	err := stmt.Execute(ctx)
+	ctx.hook.AfterStatement(ctx, stmt, err)
+	return err
+}
+
+// Evaluates the expression of the statement as executeStatement executes it
+func evaluateStatement(ctx *Context, stmt *ExpressionStatement) (Value, error) {
+	if ctx.tracer != nil {
+		ctx.traceStatement(stmt)
+	}
+	if ctx.hook == nil {
+		return stmt.expr.Evaluate(ctx)
+	}
+	if err := ctx.hook.BeforeStatement(ctx, stmt); err != nil {
+		return nil, err
+	}
+	val, err := stmt.expr.Evaluate(ctx)
+	ctx.hook.AfterStatement(ctx, stmt, err)
+	return val, err
 }
 
 func (s *BlockStatement) Execute(ctx *Context) error {
-	exit := debugEnterEnv(ctx, "<block>")
-	defer exit()
+	if err := ctx.memory.Allocate(envSize); err != nil {
+		return NewRuntimeError(err, s.Position())
+	}
+	pop := ctx.PushEnv("<block>")
+	defer pop()
 	for _, stmt := range s.stmts {
-		if err := stmt.Execute(ctx); err != nil {
+		if err := executeStatement(ctx, stmt); err != nil {
 			return err
 		}
 	}
@@ -53,15 +101,17 @@ func (s *ConditionalStatement) Execute(ctx *Context) error {
 	if err != nil {
 		return err
 	}
-	log.Debug().Msgf("(%s) start conditional", ctx.Phase())
 	if cond.Truthy() {
-		log.Debug().Msgf("(%s) took then branch", ctx.Phase())
-		if err := s.thenBranch.Execute(ctx); err != nil {
+		ctx.branch(s.pos, 0)
+		if err := executeStatement(ctx, s.thenBranch); err != nil {
 			return err
 		}
-	} else if s.elseBranch != nil {
-		log.Debug().Msgf("(%s) took else branch", ctx.Phase())
-		if err := s.elseBranch.Execute(ctx); err != nil {
+	} else {
+		ctx.branch(s.pos, 1)
+		if s.elseBranch == nil {
+			return nil
+		}
+		if err := executeStatement(ctx, s.elseBranch); err != nil {
 			return err
 		}
 	}
@@ -69,17 +119,18 @@ func (s *ConditionalStatement) Execute(ctx *Context) error {
 }
 
 func (s *WhileStatement) Execute(ctx *Context) error {
-	log.Debug().Msgf("(%s) start for loop", ctx.Phase())
 	for {
+		if err := ctx.step(); err != nil {
+			return NewRuntimeError(err, s.Position())
+		}
 		cond, err := s.expr.Evaluate(ctx)
 		if err != nil {
 			return err
 		}
 		if !cond.Truthy() {
-			log.Debug().Msgf("(%s) break while loop", ctx.Phase())
 			break
 		}
-		if err := s.body.Execute(ctx); err != nil {
+		if err := executeStatement(ctx, s.body); err != nil {
 			return err
 		}
 	}
@@ -88,21 +139,24 @@ func (s *WhileStatement) Execute(ctx *Context) error {
 
 func (s *ForStatement) Execute(ctx *Context) error {
 	if s.init != nil {
-		s.init.Execute(ctx)
+		if err := executeStatement(ctx, s.init); err != nil {
+			return err
+		}
 	}
-	log.Debug().Msgf("(%s) start for loop", ctx.Phase())
 	for {
+		if err := ctx.step(); err != nil {
+			return NewRuntimeError(err, s.Position())
+		}
 		if s.cond != nil {
 			cond, err := s.cond.Evaluate(ctx)
 			if err != nil {
 				return err
 			}
 			if !cond.Truthy() {
-				log.Debug().Msgf("(%s) break for loop", ctx.Phase())
 				break
 			}
 		}
-		if err := s.body.Execute(ctx); err != nil {
+		if err := executeStatement(ctx, s.body); err != nil {
 			return err
 		}
 		if s.incr != nil {
@@ -125,7 +179,6 @@ func (s *PrintStatement) Execute(ctx *Context) error {
 	if err != nil {
 		return err
 	}
-	log.Debug().Msgf("(%s) printing %s -> %s", ctx.Phase(), s.expr, val)
 	str, err := val.Print(ctx.printer)
 	if err != nil {
 		return err
@@ -142,7 +195,11 @@ func (s *DeclarationStatement) Execute(ctx *Context) error {
 	if err != nil {
 		return err
 	}
-	return debugSetValue(ctx.Phase(), ctx.env, s.name, val)
+	ctx.env.SetValue(s.name, val)
+	if ctx.tracer != nil {
+		ctx.trace(TraceEvent{Kind: TraceDeclare, Position: s.pos, Name: s.name, Values: []Value{val}})
+	}
+	return nil
 }
 
 func (s *FunctionDefinitionStatement) Execute(ctx *Context) error {
@@ -153,12 +210,15 @@ func (s *FunctionDefinitionStatement) Execute(ctx *Context) error {
 		env:    ctx.env,
 	}
 	ctx.funcs = append(ctx.funcs, fn)
-	log.Debug().Msgf("(%s) created user func %s(...) { ... }", ctx.Phase(), s.name)
 	val := &ValueCallable{
 		name: s.name,
 		fn:   fn,
 	}
-	return debugSetValue(ctx.Phase(), ctx.env, fn.name, val)
+	ctx.env.SetValue(fn.name, val)
+	if ctx.tracer != nil {
+		ctx.trace(TraceEvent{Kind: TraceDeclare, Position: s.pos, Name: s.name, Values: []Value{val}})
+	}
+	return nil
 }
 
 func (s *ReturnStatement) Execute(ctx *Context) error {
@@ -1,7 +1,11 @@
 package lox
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -76,3 +80,141 @@ func TestExecutor(t *testing.T) {
 		}
 	}
 }
+
+func TestExecutorStackTrace(t *testing.T) {
+	text := "fun inner(n) {\n return n + \"x\";\n}\nfun outer(n) {\n return inner(n);\n}\nprint outer(1);"
+	td := NewTestDriver(t, text)
+	td.Lex()
+	td.Parse()
+	td.TypeCheck()
+	td.Fatal()
+
+	td.Execute()
+	var rerr RuntimeError
+	if !errors.As(td.Err, &rerr) {
+		t.Fatalf("Expected execution of %q to produce a runtime error, but got %v", text, td.Err)
+	}
+	want := []StackFrame{
+		{Function: "inner", Position: Position{5, 14}},
+		{Function: "outer", Position: Position{7, 12}},
+	}
+	if rerr.Trace == nil || !reflect.DeepEqual(rerr.Trace.Frames, want) {
+		t.Fatalf("Expected trace %v, but got %v", want, rerr.Trace)
+	}
+	traceback := "Traceback (most recent call last):\n  line 7, in <script>\n  line 5, in outer\n  line 2, in inner\n"
+	if tb := Traceback(td.Err); tb != traceback {
+		t.Errorf("Expected traceback %q, but got %q", traceback, tb)
+	}
+}
+
+func TestExecutorWrappedStackTrace(t *testing.T) {
+	interp, err := NewInterpreter(WithStdout(&PrintSpy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cause := NewRuntimeError(NewResultTooLargeError("fail"), Position{9, 9})
+	fail, err := FromGo(func() error { return fmt.Errorf("wrapped: %w", cause) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	interp.SetGlobal("fail", fail)
+	text := "fun f() {\n return fail();\n}\nf();"
+	_, err = interp.Eval(text)
+	var rerr RuntimeError
+	if !errors.As(err, &rerr) || rerr.Position != cause.Position {
+		t.Fatalf("Expected evaluation of %q to produce %v, but got %v", text, cause, err)
+	}
+	want := []StackFrame{{Function: "f", Position: Position{4, 2}}}
+	if rerr.Trace == nil || !reflect.DeepEqual(rerr.Trace.Frames, want) {
+		t.Fatalf("Expected trace %v, but got %v", want, rerr.Trace)
+	}
+	if !errors.Is(err, cause) || !strings.Contains(err.Error(), "wrapped: ") {
+		t.Errorf("Expected the wrapped error to be kept, but got %v", err)
+	}
+}
+
+func TestExecutorInterrupted(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		text   string
+		budget int
+		cancel context.Context
+		err    error
+	}{
+		{text: "while (true) {}", budget: 100, err: ErrBudgetExceeded},
+		{text: "for (;;) {}", budget: 100, err: ErrBudgetExceeded},
+		{text: "fun f() { f(); }\n f();", budget: 100, err: ErrBudgetExceeded},
+		{text: "var x = 0; while (x < 10) { x = x + 1; }", budget: 100},
+		{text: "while (true) {}", cancel: cancelled, err: ErrCancelled},
+		{text: "fun f() { f(); }\n f();", cancel: cancelled, err: ErrCancelled},
+		{text: "sleep(60);", cancel: cancelled, err: ErrCancelled},
+	}
+	for _, test := range tests {
+		td := NewTestDriver(t, test.text)
+		td.ctx.SetStepBudget(test.budget)
+		if test.cancel != nil {
+			td.ctx.SetContext(test.cancel)
+		}
+		td.Lex()
+		td.Parse()
+		td.TypeCheck()
+		td.Fatal()
+
+		td.Execute()
+		if test.err == nil {
+			if td.Err != nil {
+				t.Errorf("Unexpected error in %q: %s", test.text, td.Err)
+			}
+			continue
+		}
+		if !errors.Is(td.Err, test.err) {
+			t.Errorf("Expected execution of %q to produce error %q, but got %v", test.text, test.err, td.Err)
+		}
+	}
+}
+
+func TestExecutorResourceLimits(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		err   bool
+	}{
+		{text: "var s = \"ab\"; while (true) { s = s + s; }", limit: 1 << 16, err: true},
+		{text: "while (true) { {} }", limit: 1 << 16, err: true},
+		{text: "fun f() { f(); }\n f();", limit: 1 << 16, err: true},
+		{text: "var s = \"ab\"; while (true) s = replace(s, \"a\", s);", limit: 1 << 16, err: true},
+		{text: "var s = \"ab\"; while (true) s = join(list(s, s), \"\");", limit: 1 << 16, err: true},
+		{text: "var s = \"ab\"; while (true) s = upper(s);", limit: 1 << 16, err: true},
+		{text: "var s = \"ab\"; while (true) chars(s);", limit: 1 << 16, err: true},
+		{text: "var s = \"ab\"; while (true) s.split(\"\");", limit: 1 << 16, err: true},
+		{text: "var s = \"ab\"; while (true) s = re.replace(\"a\", s, s);", limit: 1 << 16, err: true},
+		{text: "var s = \"ab\"; while (true) re.findAll(\".\", s);", limit: 1 << 16, err: true},
+		{text: "var s = \"ab\"; while (true) re.split(\"\", s);", limit: 1 << 16, err: true},
+		{text: "var s = \"a\"; for (var i = 0; i < 10; i = i + 1) { s = s + \"a\"; }", limit: 1 << 16},
+	}
+	for _, test := range tests {
+		td := NewTestDriver(t, test.text)
+		td.ctx.SetMemoryLimit(test.limit)
+		td.Lex()
+		td.Parse()
+		td.TypeCheck()
+		td.Fatal()
+
+		td.Execute()
+		if !test.err {
+			if td.Err != nil {
+				t.Errorf("Unexpected error in %q: %s", test.text, td.Err)
+			}
+			continue
+		}
+		var limitErr ResourceLimitError
+		if !errors.As(td.Err, &limitErr) {
+			t.Errorf("Expected execution of %q to produce a resource limit error, but got %v", test.text, td.Err)
+			continue
+		}
+		if limitErr.Limit != test.limit || limitErr.Used <= test.limit {
+			t.Errorf("Unexpected resource limit error in %q: %s", test.text, limitErr)
+		}
+	}
+}
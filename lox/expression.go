@@ -226,6 +226,37 @@ func (e *CallExpression) Equals(other Expression) bool {
 	return e.callee.Equals(call.callee)
 }
 
+type PropertyExpression struct {
+	object Expression
+	name   string
+	pos    Position
+	typ    Type
+}
+
+func (e *PropertyExpression) Position() Position {
+	return e.pos
+}
+
+func (e *PropertyExpression) String() string {
+	str, err := e.Print(&defaultPrinter)
+	if err != nil {
+		panic(err)
+	}
+	return str
+}
+
+func (e *PropertyExpression) Type() Type {
+	return e.typ
+}
+
+func (e *PropertyExpression) Equals(other Expression) bool {
+	prop, ok := other.(*PropertyExpression)
+	if !ok || e.name != prop.name {
+		return false
+	}
+	return e.object.Equals(prop.object)
+}
+
 type StringExpression struct {
 	value string
 	pos   Position
@@ -0,0 +1,183 @@
+package lox
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+var fileNatives = []*BuiltinFunction{
+	NewBuiltinFunction("readFile", 1, []Type{TypeString}, TypeString, readFile),
+	NewBuiltinFunction("writeFile", 2, []Type{TypeString, TypeString}, TypeNil, writeFile),
+	NewBuiltinFunction("appendFile", 2, []Type{TypeString, TypeString}, TypeNil, appendFile),
+	NewBuiltinFunction("readLine", 0, nil, TypeString.Union(TypeNil), readLine),
+	NewBuiltinFunction("listDir", 1, []Type{TypeString}, TypeList, listDir),
+	NewBuiltinFunction("exists", 1, []Type{TypeString}, TypeBoolean, exists),
+}
+
+// Returns the contents of the file
+func readFile(ctx *Context, args ...Value) (Value, error) {
+	path, err := ctx.permitPath("readFile", args[0])
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, NewFileError("readFile", path, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, NewFileError("readFile", path, err)
+	}
+	// the size is charged before reading, and no more than it is read
+	if err := ctx.memory.Allocate(int(info.Size())); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(f, info.Size()))
+	if err != nil {
+		return nil, NewFileError("readFile", path, err)
+	}
+	return ValueString(data), nil
+}
+
+// Replaces the contents of the file, creating it if necessary
+func writeFile(ctx *Context, args ...Value) (Value, error) {
+	return Nil, writeTo(ctx, "writeFile", os.O_TRUNC, args...)
+}
+
+// Appends to the contents of the file, creating it if necessary
+func appendFile(ctx *Context, args ...Value) (Value, error) {
+	return Nil, writeTo(ctx, "appendFile", os.O_APPEND, args...)
+}
+
+func writeTo(ctx *Context, op string, flag int, args ...Value) error {
+	path, err := ctx.permitPath(op, args[0])
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|flag, 0o644)
+	if err != nil {
+		return NewFileError(op, path, err)
+	}
+	_, err = io.WriteString(f, string(args[1].(ValueString)))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return NewFileError(op, path, err)
+	}
+	return nil
+}
+
+// Returns the next line of input without its line ending, or nil once
+// input is exhausted
+func readLine(ctx *Context, _ ...Value) (Value, error) {
+	if ctx.stdin == nil {
+		return nil, NewFileAccessDeniedError("readLine", "<stdin>")
+	}
+	line, err := ctx.stdin.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return Nil, nil
+	} else if err != nil && err != io.EOF {
+		return nil, NewFileError("readLine", "<stdin>", err)
+	}
+	if err := ctx.memory.Allocate(len(line)); err != nil {
+		return nil, err
+	}
+	return ValueString(strings.TrimRight(line, "\r\n")), nil
+}
+
+// Returns the sorted names of the entries in the directory
+func listDir(ctx *Context, args ...Value) (Value, error) {
+	path, err := ctx.permitPath("listDir", args[0])
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, NewFileError("listDir", path, err)
+	}
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	sort.Strings(names)
+	return stringList(names), nil
+}
+
+func exists(ctx *Context, args ...Value) (Value, error) {
+	path, err := ctx.permitPath("exists", args[0])
+	if err != nil {
+		return nil, err
+	}
+	_, err = os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return False, nil
+	} else if err != nil {
+		return nil, NewFileError("exists", path, err)
+	}
+	return True, nil
+}
+
+// Returns the resolved path if it lies beneath one of the permitted roots
+func (ctx *Context) permitPath(op string, arg Value) (string, error) {
+	path := string(arg.(ValueString))
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return "", NewFileError(op, path, err)
+	}
+	for _, root := range ctx.roots {
+		if rel, err := filepath.Rel(root, resolved); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolved, nil
+		}
+	}
+	return "", NewFileAccessDeniedError(op, path)
+}
+
+// Maximum number of dangling symbolic links followed in resolving a path
+const maxDanglingLinks = 40
+
+// Returns the absolute path with symbolic links in its existing prefix
+// evaluated, so that links cannot escape a permitted root. A dangling link
+// is resolved to its target, which creating the file would follow.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	var rest []string
+	for links := 0; ; {
+		resolved, err := filepath.EvalSymlinks(abs)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...), nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		if info, err := os.Lstat(abs); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			if links += 1; links > maxDanglingLinks {
+				return "", &fs.PathError{Op: "resolve", Path: path, Err: syscall.ELOOP}
+			}
+			target, err := os.Readlink(abs)
+			if err != nil {
+				return "", err
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(abs), target)
+			}
+			abs = filepath.Clean(target)
+			continue
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return filepath.Join(append([]string{abs}, rest...)...), nil
+		}
+		rest = append([]string{filepath.Base(abs)}, rest...)
+		abs = parent
+	}
+}
@@ -0,0 +1,137 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestFileNatives(t *testing.T) {
+	root, outside := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "created"), filepath.Join(root, "dangling")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "dir", "created"), filepath.Join(root, "danglingDir")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("file", filepath.Join(root, "relative")); err != nil {
+		t.Fatal(err)
+	}
+	interp, err := NewInterpreter(WithStdout(&PrintSpy{}), WithFileAccess(root), WithStdin(strings.NewReader("one\r\ntwo")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := func(name string) string {
+		return filepath.Join(root, name)
+	}
+	tests := []struct {
+		text string
+		val  Value
+		err  error
+	}{
+		{text: fmt.Sprintf("exists(%q);", path("file")), val: False},
+		{text: fmt.Sprintf("writeFile(%q, \"a\");", path("file")), val: Nil},
+		{text: fmt.Sprintf("appendFile(%q, \"b\");", path("file")), val: Nil},
+		{text: fmt.Sprintf("exists(%q);", path("file")), val: True},
+		{text: fmt.Sprintf("readFile(%q);", path("file")), val: ValueString("ab")},
+		{text: fmt.Sprintf("writeFile(%q, \"c\"); readFile(%q);", path("file"), path("file")), val: ValueString("c")},
+		{text: fmt.Sprintf("listDir(%q);", root), val: NewValueList(
+			ValueString("dangling"), ValueString("danglingDir"), ValueString("file"), ValueString("link"), ValueString("relative"),
+		)},
+		{text: fmt.Sprintf("readFile(%q);", path("relative")), val: ValueString("c")},
+		{text: "readLine();", val: ValueString("one")},
+		{text: "readLine();", val: ValueString("two")},
+		{text: "readLine();", val: Nil},
+		{
+			text: fmt.Sprintf("readFile(%q);", path("missing")),
+			err:  NewRuntimeError(NewFileError("readFile", path("missing"), syscall.ENOENT), Position{1, 9}),
+		},
+		{
+			text: fmt.Sprintf("readFile(%q);", filepath.Join(outside, "secret")),
+			err:  NewRuntimeError(NewFileAccessDeniedError("readFile", filepath.Join(outside, "secret")), Position{1, 9}),
+		},
+		{
+			text: fmt.Sprintf("readFile(%q);", path("link/secret")),
+			err:  NewRuntimeError(NewFileAccessDeniedError("readFile", path("link/secret")), Position{1, 9}),
+		},
+		{
+			text: fmt.Sprintf("writeFile(%q, \"x\");", path("dangling")),
+			err:  NewRuntimeError(NewFileAccessDeniedError("writeFile", path("dangling")), Position{1, 10}),
+		},
+		{
+			text: fmt.Sprintf("appendFile(%q, \"x\");", path("danglingDir")),
+			err:  NewRuntimeError(NewFileAccessDeniedError("appendFile", path("danglingDir")), Position{1, 11}),
+		},
+		{
+			text: fmt.Sprintf("exists(%q);", path("../other")),
+			err:  NewRuntimeError(NewFileAccessDeniedError("exists", path("../other")), Position{1, 7}),
+		},
+	}
+	for _, test := range tests {
+		val, err := interp.Eval(test.text)
+		if test.err != nil {
+			if err != test.err {
+				t.Errorf("Expected %q to produce error %q, but got %v", test.text, test.err, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("Unexpected error in %q: %s", test.text, err)
+			continue
+		}
+		if !test.val.Equals(val) {
+			t.Errorf("Expected %q to yield %s, but got %s", test.text, test.val, val)
+		}
+	}
+	for _, name := range []string{"created", "dir"} {
+		if _, err := os.Lstat(filepath.Join(outside, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to have been created outside of the root", name)
+		}
+	}
+}
+
+func TestReadFileMemoryLimit(t *testing.T) {
+	root := t.TempDir()
+	name := filepath.Join(root, "large")
+	if err := os.WriteFile(name, make([]byte, 4096), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	interp, err := NewInterpreter(WithStdout(&PrintSpy{}), WithFileAccess(root), WithMemoryLimit(1024))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := fmt.Sprintf("readFile(%q);", name)
+	var limitErr ResourceLimitError
+	if _, err := interp.Eval(text); !errors.As(err, &limitErr) {
+		t.Errorf("Expected %q to exceed the memory limit, but got %v", text, err)
+	}
+}
+
+func TestFileNativesDenied(t *testing.T) {
+	interp, err := NewInterpreter(WithStdout(&PrintSpy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "file")
+	for _, text := range []string{
+		fmt.Sprintf("writeFile(%q, \"a\");", name),
+		fmt.Sprintf("exists(%q);", name),
+		"readLine();",
+	} {
+		if _, err := interp.Eval(text); err == nil {
+			t.Errorf("Expected %q to be denied", text)
+		}
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("Expected %s not to have been written", name)
+	}
+}
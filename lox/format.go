@@ -0,0 +1,272 @@
+package lox
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Position following every token of the source
+var endOfSource = Position{Line: math.MaxInt, Column: math.MaxInt}
+
+// Formats the program read from r as indented Lox source, preserving comments
+func FormatSource(ctx *Context, r io.Reader) (string, error) {
+	tokens, err := Scan(ctx, bufio.NewReader(r))
+	if err != nil {
+		return "", err
+	}
+	return Format(ctx, tokens)
+}
+
+// Formats the scanned program as indented Lox source, preserving comments
+func Format(ctx *Context, tokens []Token) (string, error) {
+	stmts, err := Parse(ctx, tokens)
+	if err != nil {
+		return "", err
+	}
+	f := NewFormatter(tokens)
+	for _, stmt := range stmts {
+		f.statement(0, stmt)
+	}
+	f.flush(0, endOfSource)
+	if len(f.lines) == 0 {
+		return "", nil
+	}
+	return strings.Join(f.lines, "\n") + "\n", nil
+}
+
+// Renders statements back to source. Comments, which the parser discards,
+// are kept in source order and emitted before the first node positioned
+// after them. A comment following code on the same line stays at the end of
+// the line it was on.
+type Formatter struct {
+	lines    []string
+	comments []Token            // pending comments, in source order
+	trailing map[Position]bool  // comments which follow code on their line
+	braces   map[Position]Token // closing brace of each opening brace
+	opening  []Position         // opening braces, in source order
+	blank    map[int]bool       // lines preceded by a blank line
+	join     bool               // whether the next line continues the last
+}
+
+func NewFormatter(tokens []Token) *Formatter {
+	f := &Formatter{
+		trailing: map[Position]bool{},
+		braces:   map[Position]Token{},
+		blank:    map[int]bool{},
+	}
+	var open []Position
+	prev := Token{Type: TokenEOF}
+	for _, token := range tokens {
+		switch token.Type {
+		case TokenComment:
+			f.comments = append(f.comments, token)
+			if prev.Type != TokenEOF && prev.Position.Line == token.Position.Line {
+				f.trailing[token.Position] = true
+			}
+		case TokenLeftBrace:
+			open = append(open, token.Position)
+			f.opening = append(f.opening, token.Position)
+		case TokenRightBrace:
+			if n := len(open); n > 0 {
+				f.braces[open[n-1]] = token
+				open = open[:n-1]
+			}
+		}
+		if prev.Type != TokenEOF && token.Position.Line > prev.Position.Line+1 {
+			f.blank[token.Position.Line] = true
+		}
+		prev = token
+	}
+	return f
+}
+
+// Position of the brace closing the first block opened at or after pos
+func (f *Formatter) closingBrace(pos Position) Position {
+	for _, open := range f.opening {
+		if !open.Before(pos) {
+			return f.braces[open].Position
+		}
+	}
+	return endOfSource
+}
+
+// Emits the pending comments positioned before pos
+func (f *Formatter) flush(depth int, pos Position) {
+	for len(f.comments) > 0 {
+		comment := f.comments[0]
+		if !comment.Position.Before(pos) {
+			return
+		}
+		f.comments = f.comments[1:]
+		text := "//" + strings.TrimRight(comment.Lexem, " \t\r")
+		if f.trailing[comment.Position] && len(f.lines) > 0 {
+			f.lines[len(f.lines)-1] += " " + text
+			continue
+		}
+		f.join = false
+		f.line(depth, comment.Position, text)
+	}
+}
+
+// Starts a line for the node at pos, separated from the previous one by a
+// blank line if the source was. If join is set it continues the last line.
+func (f *Formatter) line(depth int, pos Position, text string) {
+	if f.join && len(f.lines) > 0 {
+		f.join = false
+		f.lines[len(f.lines)-1] += " " + text
+		return
+	}
+	f.join = false
+	if n := len(f.lines); n > 0 && f.blank[pos.Line] && !strings.HasSuffix(f.lines[n-1], "{") {
+		f.lines = append(f.lines, "")
+	}
+	f.lines = append(f.lines, strings.Repeat("\t", depth)+text)
+}
+
+func (f *Formatter) statement(depth int, stmt Statement) {
+	f.flush(depth, stmt.Position())
+	switch s := stmt.(type) {
+	case *BlockStatement:
+		f.line(depth, s.pos, "{")
+		f.body(depth, s.stmts, f.closingBrace(s.pos))
+	case *ConditionalStatement:
+		f.line(depth, s.pos, f.header(s))
+		f.branch(depth, s.thenBranch)
+		if s.elseBranch == nil {
+			break
+		}
+		_, block := s.thenBranch.(*BlockStatement)
+		f.join = block
+		f.line(depth, s.elseBranch.Position(), "else")
+		if _, ok := s.elseBranch.(*ConditionalStatement); ok {
+			f.join = true
+			f.statement(depth, s.elseBranch)
+		} else {
+			f.branch(depth, s.elseBranch)
+		}
+	case *WhileStatement:
+		f.line(depth, s.pos, f.header(s))
+		f.branch(depth, s.body)
+	case *ForStatement:
+		f.line(depth, s.pos, f.header(s))
+		f.branch(depth, s.body)
+	case *FunctionDefinitionStatement:
+		f.line(depth, s.pos, f.header(s)+" {")
+		f.body(depth, s.body, f.closingBrace(s.pos))
+	default:
+		f.line(depth, stmt.Position(), f.simple(stmt))
+	}
+}
+
+// Renders the line introducing a compound statement, or the whole of a
+// statement which fits on a single line
+func (f *Formatter) header(stmt Statement) string {
+	switch s := stmt.(type) {
+	case *BlockStatement:
+		return "{"
+	case *ConditionalStatement:
+		return "if (" + f.expression(s.expr) + ")"
+	case *WhileStatement:
+		return "while (" + f.expression(s.expr) + ")"
+	case *ForStatement:
+		clause := ";"
+		if s.init != nil {
+			clause = f.simple(s.init)
+		}
+		if s.cond != nil {
+			clause += " " + f.expression(s.cond)
+		}
+		clause += ";"
+		if s.incr != nil {
+			clause += " " + f.expression(s.incr)
+		}
+		return "for (" + clause + ")"
+	case *FunctionDefinitionStatement:
+		return "fun " + s.name + "(" + strings.Join(s.params, ", ") + ")"
+	}
+	return f.simple(stmt)
+}
+
+// Renders a statement which fits on a single line
+func (f *Formatter) simple(stmt Statement) string {
+	switch s := stmt.(type) {
+	case *ExpressionStatement:
+		return f.expression(s.expr) + ";"
+	case *PrintStatement:
+		return "print " + f.expression(s.expr) + ";"
+	case *DeclarationStatement:
+		// an omitted initializer is positioned at the name
+		if nilExpr, ok := s.expr.(*NilExpression); ok && nilExpr.pos == s.pos {
+			return "var " + s.name + ";"
+		}
+		return "var " + s.name + " = " + f.expression(s.expr) + ";"
+	case *ReturnStatement:
+		// an omitted value has no position
+		if nilExpr, ok := s.expr.(*NilExpression); ok && nilExpr.pos == (Position{}) {
+			return "return;"
+		}
+		return "return " + f.expression(s.expr) + ";"
+	}
+	return stmt.String()
+}
+
+// Renders the body of a conditional or loop, keeping a block on the same
+// line as its header
+func (f *Formatter) branch(depth int, stmt Statement) {
+	if _, ok := stmt.(*BlockStatement); ok {
+		f.join = true
+		f.statement(depth, stmt)
+		return
+	}
+	f.statement(depth+1, stmt)
+}
+
+// Renders the statements of a block whose opening brace has been emitted,
+// followed by the closing brace at end
+func (f *Formatter) body(depth int, stmts []Statement, end Position) {
+	open, header := len(f.lines), f.lines[len(f.lines)-1]
+	for _, stmt := range stmts {
+		f.statement(depth+1, stmt)
+	}
+	f.flush(depth+1, end)
+	if len(f.lines) == open && f.lines[open-1] == header {
+		f.lines[open-1] += "}"
+		return
+	}
+	f.lines = append(f.lines, strings.Repeat("\t", depth)+"}")
+}
+
+func (f *Formatter) expression(expr Expression) string {
+	switch e := expr.(type) {
+	case *UnaryExpression:
+		return e.op.Lexem + f.expression(e.right)
+	case *BinaryExpression:
+		return f.expression(e.left) + " " + e.op.Lexem + " " + f.expression(e.right)
+	case *GroupingExpression:
+		return "(" + f.expression(e.expr) + ")"
+	case *AssignmentExpression:
+		return e.name + " = " + f.expression(e.right)
+	case *VariableExpression:
+		return e.name
+	case *CallExpression:
+		args := make([]string, len(e.args))
+		for i, arg := range e.args {
+			args[i] = f.expression(arg)
+		}
+		return f.expression(e.callee) + "(" + strings.Join(args, ", ") + ")"
+	case *PropertyExpression:
+		return f.expression(e.object) + "." + e.name
+	case *StringExpression:
+		return "\"" + e.value + "\""
+	case *NumericExpression:
+		return strconv.FormatFloat(e.value, 'f', -1, 64)
+	case *BooleanExpression:
+		return strconv.FormatBool(e.value)
+	case *NilExpression:
+		return "nil"
+	}
+	return expr.String()
+}
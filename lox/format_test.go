@@ -0,0 +1,104 @@
+package lox
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	for _, test := range []struct {
+		text string
+		want string
+	}{
+		{text: "print 1+2*3;", want: "print 1 + 2 * 3;\n"},
+		{text: "var a=(1+2)*-3;var b;", want: "var a = (1 + 2) * -3;\nvar b;\n"},
+		{text: "x=f(1,\"s\").y;", want: "x = f(1, \"s\").y;\n"},
+		{text: "{print 1;{}}", want: "{\n\tprint 1;\n\t{}\n}\n"},
+		{text: "fun f(a,b){return;}", want: "fun f(a, b) {\n\treturn;\n}\n"},
+		{text: "fun f(){return nil;}", want: "fun f() {\n\treturn nil;\n}\n"},
+		{text: "if(a)print 1;else print 2;", want: "if (a)\n\tprint 1;\nelse\n\tprint 2;\n"},
+		{text: "if(a){print 1;}else if(b){print 2;}else{print 3;}",
+			want: "if (a) {\n\tprint 1;\n} else if (b) {\n\tprint 2;\n} else {\n\tprint 3;\n}\n"},
+		{text: "while(!a and b or c){a=true;}", want: "while (!a and b or c) {\n\ta = true;\n}\n"},
+		{text: "for(;;){}", want: "for (;;) {}\n"},
+		{text: "for(var i=0;i<=1.5;i=i+1)print i;", want: "for (var i = 0; i <= 1.5; i = i + 1)\n\tprint i;\n"},
+		{text: "for(i=0;;)print i;", want: "for (i = 0;;)\n\tprint i;\n"},
+		{text: "// a\nprint 1; // b\n\n\n// c\nprint 2;\n// d\n", want: "// a\nprint 1; // b\n\n// c\nprint 2;\n// d\n"},
+		{text: "{ // a\nprint 1;\n// b\n} // c\n", want: "{ // a\n\tprint 1;\n\t// b\n} // c\n"},
+		{text: "fun f() {\n// a\n}", want: "fun f() {\n\t// a\n}\n"},
+		{text: "", want: ""},
+	} {
+		got, err := FormatSource(NewContext(&PrintSpy{}), strings.NewReader(test.text))
+		if err != nil {
+			t.Errorf("Unexpected error formatting %q: %s", test.text, err)
+		} else if got != test.want {
+			t.Errorf("Expected %q to format as %q, but got %q", test.text, test.want, got)
+		}
+	}
+}
+
+// Formatting must preserve the program and be stable
+func TestFormatRoundTrip(t *testing.T) {
+	var paths []string
+	err := filepath.WalkDir(conformanceRoot, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && filepath.Ext(path) == ".lox" {
+			paths = append(paths, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures, _ := filepath.Glob(filepath.Join("fixtures", "*.lox"))
+	for _, path := range append(fixtures, paths...) {
+		text, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := NewContext(&PrintSpy{})
+		tokens, err := Scan(ctx, strings.NewReader(string(text)))
+		if err != nil {
+			continue
+		}
+		program, err := Parse(ctx, tokens)
+		if err != nil {
+			continue
+		}
+		formatted, err := Format(ctx, tokens)
+		if err != nil {
+			t.Fatalf("Unexpected error formatting %s: %s", path, err)
+		}
+		reformatted, err := FormatSource(ctx, strings.NewReader(formatted))
+		if err != nil {
+			t.Fatalf("Unexpected error reformatting %s: %s", path, err)
+		}
+		if reformatted != formatted {
+			t.Errorf("Expected formatting of %s to be stable, but got:\n%s\nthen:\n%s", path, formatted, reformatted)
+		}
+		reparsed, err := Parse(ctx, mustScan(t, ctx, formatted))
+		if err != nil || len(reparsed) != len(program) {
+			t.Errorf("Expected formatted %s to parse to %d statements, but got %d (%v)", path, len(program), len(reparsed), err)
+			continue
+		}
+		for i, stmt := range program {
+			if !stmt.Equals(reparsed[i]) {
+				t.Errorf("Expected formatted %s to parse to %s, but got %s", path, stmt, reparsed[i])
+			}
+		}
+		if strings.Count(formatted, "//") < strings.Count(string(text), "//") {
+			t.Errorf("Expected formatting of %s to preserve comments:\n%s", path, formatted)
+		}
+	}
+}
+
+func mustScan(t *testing.T, ctx *Context, text string) []Token {
+	t.Helper()
+	tokens, err := Scan(ctx, strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("Unexpected error scanning %q: %s", text, err)
+	}
+	return tokens
+}
@@ -1,7 +1,9 @@
 package lox
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 )
@@ -11,17 +13,96 @@ type Function interface {
 	Execute(*Context, string, ...Value) (Value, error)
 }
 
+// Implementation of a function provided by the host
+type NativeFunc func(*Context, ...Value) (Value, error)
+
+// Arity of a native accepting any number of arguments
+const Variadic = -1
+
 type BuiltinFunction struct {
-	name string
-	exec func(*Context, ...Value) (Value, error)
+	name   string
+	arity  int    // number of required arguments, or Variadic
+	params []Type // parameter types, those beyond arity being optional
+	ret    Type   // type of the returned value
+	exec   NativeFunc
+}
+
+func NewBuiltinFunction(name string, arity int, params []Type, ret Type, fn NativeFunc) *BuiltinFunction {
+	return &BuiltinFunction{
+		name:   name,
+		arity:  arity,
+		params: params,
+		ret:    ret,
+		exec:   fn,
+	}
 }
 
 func (f *BuiltinFunction) String() string {
 	return fmt.Sprintf("BuiltinFunction(%s)", f.name)
 }
 
+func (f *BuiltinFunction) Arity() int {
+	return f.arity
+}
+
+func (f *BuiltinFunction) ReturnType() Type {
+	return f.ret
+}
+
+// Returns the signature of the function, such as substr(String, Numeric, Numeric?) -> String
+func (f *BuiltinFunction) Signature() string {
+	params := make([]string, len(f.params))
+	for i, param := range f.params {
+		params[i] = param.Name()
+		if f.arity == Variadic && i == len(f.params)-1 {
+			params[i] += "..."
+		} else if f.arity != Variadic && i >= f.arity {
+			params[i] += "?"
+		}
+	}
+	return fmt.Sprintf("%s(%s) -> %s", f.name, strings.Join(params, ", "), f.ret.Name())
+}
+
+// Returns the declared type of the i-th parameter, if any
+func (f *BuiltinFunction) ParamType(i int) (Type, bool) {
+	if f.arity == Variadic && len(f.params) > 0 {
+		return f.params[min(i, len(f.params)-1)], true
+	}
+	if i < len(f.params) {
+		return f.params[i], true
+	}
+	return TypeNone, false
+}
+
+// Verifies that the function accepts the given number of arguments
+func (f *BuiltinFunction) CheckArity(nargs int) error {
+	if f.arity == Variadic {
+		return nil
+	}
+	if nargs < f.arity || nargs > max(f.arity, len(f.params)) {
+		return NewArityMismatchError(f.arity, nargs)
+	}
+	return nil
+}
+
+// Verifies that the arguments match the function's signature
+func (f *BuiltinFunction) CheckArgs(args ...Value) error {
+	if err := f.CheckArity(len(args)); err != nil {
+		return err
+	}
+	for i, arg := range args {
+		if param, ok := f.ParamType(i); ok && !arg.Type().Test(param) {
+			return NewInvalidArgumentTypeError(f.name, i, arg.Type(), param)
+		}
+	}
+	return nil
+}
+
 func (f *BuiltinFunction) Execute(ctx *Context, _ string, args ...Value) (Value, error) {
 	log.Debug().Msgf("(%s) executing %s with %v", ctx.Phase(), f.String(), args)
+	if err := f.CheckArgs(args...); err != nil {
+		return nil, err
+	}
 	return f.exec(ctx, args...)
 }
 
@@ -79,11 +160,13 @@ func (e ReturnErr) Error() string {
 	return "return"
 }
 
-func (f *UserFunction) Execute(ctx *Context, name string, args ...Value) (Value, error) {
-	log.Debug().Msgf("(%s) executing %s with %v", ctx.Phase(), f.String(), args)
+func (f *UserFunction) Execute(ctx *Context, name string, args ...Value) (val Value, err error) {
 	if len(args) != len(f.params) {
 		return nil, NewArityMismatchError(f.Arity(), len(args))
 	}
+	if err := ctx.step(); err != nil {
+		return nil, err
+	}
 
 	if ctx.env != f.env {
 		prevEnv := ctx.env
@@ -91,20 +174,50 @@ func (f *UserFunction) Execute(ctx *Context, name string, args ...Value) (Value,
 			ctx.env = prevEnv
 		}()
 		ctx.env = f.env
-		log.Debug().Msgf("(%s) CHANGE %s -> %s", ctx.Phase(), prevEnv, ctx.env)
 	}
-	exit := debugEnterEnv(ctx, name)
-	defer exit()
+	if err := ctx.memory.Allocate(envSize); err != nil {
+		return nil, err
+	}
+	pop := ctx.PushEnv(name)
+	defer pop()
 	for i, arg := range args {
-		if err := debugSetValue(ctx.Phase(), ctx.env, f.params[i], arg); err != nil {
+		ctx.env.SetValue(f.params[i], arg)
+	}
+	if ctx.tracer != nil {
+		caller, _ := ctx.Caller()
+		ctx.trace(TraceEvent{Kind: TraceCall, Position: caller.Position, Name: name, Values: args})
+		defer func() {
+			ev := TraceEvent{Kind: TraceReturn, Position: caller.Position, Name: name, Err: err}
+			if err == nil {
+				ev.Values = []Value{val}
+			}
+			ctx.trace(ev)
+		}()
+	}
+	if ctx.hook != nil {
+		if err := ctx.hook.EnterFunction(ctx, name, args); err != nil {
 			return nil, err
 		}
+		defer func() {
+			ctx.hook.ExitFunction(ctx, name, val, err)
+		}()
 	}
 	for _, s := range f.body {
-		if err := s.Execute(ctx); err != nil {
+		if err := executeStatement(ctx, s); err != nil {
 			if ret, ok := err.(ReturnErr); ok {
 				return ret.val, nil
 			}
+			var rerr RuntimeError
+			if errors.As(err, &rerr) {
+				caller, _ := ctx.Caller()
+				frame := StackFrame{Function: name, Position: caller.Position}
+				if _, ok := err.(RuntimeError); ok {
+					err = rerr.WithFrame(frame)
+				} else {
+					// keep the errors wrapping rerr, continuing its trace
+					err = RuntimeError{Err: err, Position: rerr.Position, Trace: rerr.Trace}.WithFrame(frame)
+				}
+			}
 			return nil, err
 		}
 	}
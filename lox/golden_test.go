@@ -0,0 +1,118 @@
+package lox
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "regenerate the golden files of the fixtures")
+
+// Type inferred for a declaration
+type goldenDeclaration struct {
+	Name     string   `json:"Name"`
+	Position Position `json:"Position"`
+	Type     Type     `json:"Type"`
+}
+
+// Returns the declarations of the program and its nested blocks, in order
+func goldenDeclarations(stmts []Statement) []goldenDeclaration {
+	decls := []goldenDeclaration{}
+	for _, stmt := range stmts {
+		switch s := stmt.(type) {
+		case *DeclarationStatement:
+			decls = append(decls, goldenDeclaration{Name: s.name, Position: s.pos, Type: s.expr.Type()})
+		case *FunctionDefinitionStatement:
+			decls = append(decls, goldenDeclaration{Name: s.name, Position: s.pos, Type: TypeCallable})
+		case *BlockStatement:
+			decls = append(decls, goldenDeclarations(s.stmts)...)
+		case *ConditionalStatement:
+			decls = append(decls, goldenDeclarations([]Statement{s.thenBranch})...)
+			if s.elseBranch != nil {
+				decls = append(decls, goldenDeclarations([]Statement{s.elseBranch})...)
+			}
+		case *WhileStatement:
+			decls = append(decls, goldenDeclarations([]Statement{s.body})...)
+		case *ForStatement:
+			for _, st := range []Statement{s.init, s.body} {
+				if st != nil {
+					decls = append(decls, goldenDeclarations([]Statement{st})...)
+				}
+			}
+		}
+	}
+	return decls
+}
+
+func goldenJSON(v any) []byte {
+	data, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		panic(err)
+	}
+	return append(data, '\n')
+}
+
+// Compares the result to the golden file, or rewrites it with -update
+func checkGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *updateGolden {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with -update to create it): %s", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Output does not match %s (run with -update to regenerate):\n%s", path, got)
+	}
+}
+
+// Runs each fixture through every phase, checking the tokens, AST, inferred
+// declaration types and execution output against the golden files beside
+// it. Phases after a failing one have no golden file; the error is recorded
+// in the output.
+func TestGoldenFixtures(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("fixtures", "*.lox"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		name := strings.TrimSuffix(path, ".lox")
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			text, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			td := NewTestDriver(t, string(text))
+			td.Lex()
+			if td.Err == nil {
+				checkGolden(t, name+"_tokens.json", goldenJSON(td.Tokens))
+				td.Parse()
+			}
+			if td.Err == nil {
+				checkGolden(t, name+"_ast.json", goldenJSON(td.Program))
+				td.TypeCheck()
+			}
+			if td.Err == nil {
+				checkGolden(t, name+"_types.json", goldenJSON(goldenDeclarations(td.Program)))
+				td.Execute()
+			}
+			var out bytes.Buffer
+			for _, line := range td.Printer.Prints {
+				fmt.Fprintln(&out, line)
+			}
+			if td.Err != nil {
+				fmt.Fprintf(&out, "error (%s): %s\n", td.Phase(), td.Err)
+			}
+			checkGolden(t, name+"_output.txt", out.Bytes())
+		})
+	}
+}
@@ -66,6 +66,8 @@ var bOrExpr = makeBinaryExpr(orOp)
 
 var fooCallExpr = makeCallExpression(fooExpr())
 
+var fooPropExpr = makePropertyExpression(fooExpr())
+
 func makeNumericExpr(n float64) func() *NumericExpression {
 	return func() *NumericExpression {
 		return &NumericExpression{value: n}
@@ -128,6 +130,14 @@ func makeCallExpression(callee Expression) func(args ...Expression) func() *Call
 	}
 }
 
+func makePropertyExpression(object Expression) func(name string) func() *PropertyExpression {
+	return func(name string) func() *PropertyExpression {
+		return func() *PropertyExpression {
+			return &PropertyExpression{object: object, name: name}
+		}
+	}
+}
+
 type TestDriver struct {
 	Text    string
 	Tokens  []Token
@@ -0,0 +1,256 @@
+package lox
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// An Interpreter evaluates lox source on behalf of a host program.
+// Globals, functions and types declared by one evaluation remain visible
+// to the next.
+type Interpreter struct {
+	ctx *Context
+}
+
+// Configures an Interpreter
+type Option func(*Interpreter) error
+
+// Directs the output of print statements to w
+func WithStdout(w io.Writer) Option {
+	return func(in *Interpreter) error {
+		in.ctx.runtime.writer = w
+		return nil
+	}
+}
+
+// Directs diagnostic output, such as that of debug(), to w
+func WithStderr(w io.Writer) Option {
+	return func(in *Interpreter) error {
+		in.ctx.runtime.errWriter = w
+		return nil
+	}
+}
+
+// Enables logging at the specified level to w. Logging is process-wide
+// and disabled unless this option is supplied or the host configures it.
+func WithLogging(w io.Writer, level string) Option {
+	return func(in *Interpreter) error {
+		if err := SetLogLevel(level); err != nil {
+			return err
+		}
+		SetConsoleLogOutput(w)
+		return nil
+	}
+}
+
+// Interrupts execution once c is cancelled
+func WithContext(c context.Context) Option {
+	return func(in *Interpreter) error {
+		in.ctx.SetContext(c)
+		return nil
+	}
+}
+
+// Limits each evaluation or call to n steps (see Context.SetStepBudget)
+func WithStepBudget(n int) Option {
+	return func(in *Interpreter) error {
+		in.ctx.SetStepBudget(n)
+		return nil
+	}
+}
+
+// Limits the approximate number of bytes allocated by the interpreter
+func WithMemoryLimit(n int) Option {
+	return func(in *Interpreter) error {
+		in.ctx.SetMemoryLimit(n)
+		return nil
+	}
+}
+
+// Permits file natives to access paths beneath the given directories.
+// Without this option scripts have no filesystem access.
+func WithFileAccess(roots ...string) Option {
+	return func(in *Interpreter) error {
+		return in.ctx.AllowFiles(roots...)
+	}
+}
+
+// Permits readLine to read lines from r
+func WithStdin(r io.Reader) Option {
+	return func(in *Interpreter) error {
+		in.ctx.SetStdin(r)
+		return nil
+	}
+}
+
+// Sets the hook notified as the program executes
+func WithHook(h Hook) Option {
+	return func(in *Interpreter) error {
+		in.ctx.SetHook(h)
+		return nil
+	}
+}
+
+// Sets the tracer receiving execution events
+func WithTracer(t Tracer) Option {
+	return func(in *Interpreter) error {
+		in.ctx.SetTracer(t)
+		return nil
+	}
+}
+
+// Sets the arguments returned by args()
+func WithArgs(args ...string) Option {
+	return func(in *Interpreter) error {
+		in.ctx.SetArgs(args...)
+		return nil
+	}
+}
+
+// Permits getenv to read variables through lookup, such as os.LookupEnv
+func WithEnviron(lookup func(string) (string, bool)) Option {
+	return func(in *Interpreter) error {
+		in.ctx.SetEnviron(lookup)
+		return nil
+	}
+}
+
+// Reads the current time from c rather than the system clock
+func WithClock(c Clock) Option {
+	return func(in *Interpreter) error {
+		in.ctx.runtime.SetClock(c)
+		return nil
+	}
+}
+
+func NewInterpreter(opts ...Option) (*Interpreter, error) {
+	in := &Interpreter{ctx: NewContext(os.Stdout)}
+	for _, opt := range opts {
+		if err := opt(in); err != nil {
+			return nil, err
+		}
+	}
+	return in, nil
+}
+
+// Evaluates the source, yielding the value of its final statement if that
+// is an expression statement and Nil otherwise
+func (in *Interpreter) Eval(src string) (Value, error) {
+	return in.eval(strings.NewReader(src))
+}
+
+// Reports whether src ends before its last statement does, within a
+// string, block or parenthesis, such that further input could complete it
+func Incomplete(src string) bool {
+	ctx := NewContext(io.Discard)
+	tokens, err := Scan(ctx, strings.NewReader(src))
+	if err != nil {
+		return errors.As(err, &UnterminatedStringError{})
+	}
+	var open []TokenType
+	for _, token := range tokens {
+		switch token.Type {
+		case TokenLeftParen, TokenLeftBrace:
+			open = append(open, token.Type)
+		case TokenRightParen, TokenRightBrace:
+			n := len(open) - 1
+			if n < 0 || (open[n] == TokenLeftParen) != (token.Type == TokenRightParen) {
+				return false
+			}
+			open = open[:n]
+		}
+	}
+	if len(open) > 0 {
+		return true
+	}
+	_, err = Parse(ctx, tokens)
+	var unexpected UnexpectedTokenError
+	var missing MissingTerminalError
+	if errors.As(err, &unexpected) {
+		return unexpected.Actual.Type == TokenEOF
+	}
+	return errors.As(err, &missing) && missing.Token.Type == TokenEOF
+}
+
+// Evaluates the source in the file at path (see Eval)
+func (in *Interpreter) EvalFile(path string) (Value, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return in.eval(bufio.NewReader(f))
+}
+
+func (in *Interpreter) eval(rd io.Reader) (Value, error) {
+	tokens, err := Scan(in.ctx, rd)
+	if err != nil {
+		return nil, err
+	}
+	stmts, err := Parse(in.ctx, tokens)
+	if err != nil {
+		return nil, err
+	}
+	if err = Typecheck(in.ctx, stmts); err != nil {
+		return nil, err
+	}
+	return execute(in.ctx, stmts)
+}
+
+// Binds the global variable name to val, declaring it if necessary
+func (in *Interpreter) SetGlobal(name string, val Value) {
+	env := in.globals()
+	env.SetValue(name, val)
+	env.SetType(name, val.Type())
+}
+
+// Returns the value bound to the global variable name, if any
+func (in *Interpreter) Global(name string) (Value, bool) {
+	val := in.globals().Value(name)
+	return val, val != nil
+}
+
+// Calls the global function name with the arguments
+func (in *Interpreter) Call(name string, args ...Value) (Value, error) {
+	restore := in.ctx.StartPhase(PhaseExecute)
+	defer restore()
+	in.ctx.steps = 0
+
+	var callee Value
+	if callee = in.globals().Value(name); callee == nil {
+		if fn := in.ctx.runtime.Function(name); fn != nil {
+			callee = &ValueCallable{name: name, fn: fn}
+		} else {
+			return nil, NewUndefinedFunctionError(name)
+		}
+	}
+	switch call := callee.(type) {
+	case *ValueCallable:
+		return call.Call(in.ctx, args...)
+	case ValueCallable:
+		return call.Call(in.ctx, args...)
+	}
+	return nil, NewTypeNotCallableError(callee.Type())
+}
+
+func (in *Interpreter) globals() *Env {
+	env := in.ctx.env
+	for env.parent != nil {
+		env = env.parent
+	}
+	return env
+}
+
+// Registers a global native function (see Runtime.RegisterNative)
+func (in *Interpreter) RegisterNative(name string, arity int, params []Type, ret Type, fn NativeFunc) {
+	in.ctx.runtime.RegisterNative(name, arity, params, ret, fn)
+}
+
+// Registers a module of natives and constants (see Runtime.RegisterModule)
+func (in *Interpreter) RegisterModule(m *Module) {
+	in.ctx.runtime.RegisterModule(m)
+}
@@ -0,0 +1,216 @@
+package lox
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestInterpreterEval(t *testing.T) {
+	tests := []struct {
+		text   string
+		val    Value
+		prints []string
+	}{
+		{text: "1 + 2;", val: ValueNumeric(3)},
+		{text: "\"a\" + \"b\";", val: ValueString("ab")},
+		{text: "print 1;", val: Nil, prints: []string{"1"}},
+		{text: "var x = 2; x;", val: ValueNumeric(2)},
+		{text: "fun f(a) { return a * 2; }\n f(21);", val: ValueNumeric(42)},
+	}
+	for _, test := range tests {
+		var spy PrintSpy
+		interp, err := NewInterpreter(WithStdout(&spy))
+		if err != nil {
+			t.Fatal(err)
+		}
+		val, err := interp.Eval(test.text)
+		if err != nil {
+			t.Errorf("Unexpected error in %q: %s", test.text, err)
+			continue
+		}
+		if !test.val.Equals(val) {
+			t.Errorf("Expected %q to yield %s, but got %s", test.text, test.val, val)
+		}
+		if !reflect.DeepEqual(spy.Prints, test.prints) {
+			t.Errorf("Expected %q to print %v, but printed %v", test.text, test.prints, spy.Prints)
+		}
+	}
+}
+
+func TestInterpreterGlobals(t *testing.T) {
+	interp, err := NewInterpreter(WithStdout(&PrintSpy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	interp.SetGlobal("x", ValueNumeric(1))
+	if _, err := interp.Eval("var y = x + 1;"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if _, err := interp.Eval("fun add(a, b) { return a + b + y; }"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if y, ok := interp.Global("y"); !ok || !y.Equals(ValueNumeric(2)) {
+		t.Errorf("Expected global y to be 2, but got %v", y)
+	}
+	if _, ok := interp.Global("z"); ok {
+		t.Errorf("Expected global z to be undefined")
+	}
+	val, err := interp.Call("add", ValueNumeric(3), ValueNumeric(4))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !val.Equals(ValueNumeric(9)) {
+		t.Errorf("Expected add(3, 4) to yield 9, but got %s", val)
+	}
+	if _, err := interp.Call("missing"); !errors.As(err, &UndefinedFunctionError{}) {
+		t.Errorf("Expected calling missing to fail with an undefined function error, but got %v", err)
+	}
+	if _, err := interp.Call("y"); !errors.As(err, &TypeNotCallableError{}) {
+		t.Errorf("Expected calling y to fail with a type not callable error, but got %v", err)
+	}
+}
+
+func TestInterpreterEvalFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.lox")
+	if err := os.WriteFile(path, []byte("print \"hello\";"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var spy PrintSpy
+	interp, err := NewInterpreter(WithStdout(&spy), WithStepBudget(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := interp.EvalFile(path); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if want := []string{"hello"}; !reflect.DeepEqual(spy.Prints, want) {
+		t.Errorf("Expected %v, but printed %v", want, spy.Prints)
+	}
+	if _, err := interp.Eval("while (true) {}"); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Expected step budget to be exceeded, but got %v", err)
+	}
+}
+
+func TestInterpreterOutputOptions(t *testing.T) {
+	var stdout PrintSpy
+	var stderr strings.Builder
+	interp, err := NewInterpreter(WithStdout(&stdout), WithStderr(&stderr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := interp.Eval("var x = 1; debug();"); err != nil {
+		t.Fatal(err)
+	}
+	if len(stdout.Prints) != 0 || !strings.HasPrefix(stderr.String(), "=== DEBUG ===\n") {
+		t.Errorf("Expected debug() to write to stderr only, but got stdout %q and stderr %q", stdout.Prints, stderr.String())
+	}
+
+	if zerolog.GlobalLevel() != zerolog.Disabled {
+		t.Errorf("Expected logging to be disabled by default, but got level %s", zerolog.GlobalLevel())
+	}
+	if _, err := NewInterpreter(WithLogging(io.Discard, "loud")); err == nil {
+		t.Errorf("Expected an invalid log level to be rejected")
+	}
+	t.Cleanup(DisableLogger)
+	var logs strings.Builder
+	interp, err = NewInterpreter(WithStdout(&PrintSpy{}), WithLogging(&logs, "trace"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := interp.Eval("print 1;"); err != nil {
+		t.Fatal(err)
+	}
+	if logs.Len() == 0 {
+		t.Errorf("Expected evaluation to be logged")
+	}
+}
+
+func TestInterpreterRegisterNative(t *testing.T) {
+	interp, err := NewInterpreter(WithStdout(&PrintSpy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	interp.RegisterNative("double", 1, []Type{TypeNumeric}, TypeNumeric, func(_ *Context, args ...Value) (Value, error) {
+		return args[0].(ValueNumeric) * 2, nil
+	})
+	greet := NewModule("greet")
+	greet.RegisterNative("hello", 1, []Type{TypeString, TypeString}, TypeString, func(_ *Context, args ...Value) (Value, error) {
+		greeting := ValueString("hello")
+		if len(args) > 1 {
+			greeting = args[1].(ValueString)
+		}
+		return ValueString(string(greeting) + ", " + string(args[0].(ValueString))), nil
+	})
+	greet.SetConstant("name", ValueString("lox"))
+	interp.RegisterModule(greet)
+
+	tests := []struct {
+		text string
+		val  Value
+		err  error
+	}{
+		{text: "double(21);", val: ValueNumeric(42)},
+		{text: "double(21) + 1;", val: ValueNumeric(43)},
+		{text: "var f = double; f(2);", val: ValueNumeric(4)},
+		{text: "greet.hello(greet.name);", val: ValueString("hello, lox")},
+		{text: "greet.hello(\"you\", \"hi\");", val: ValueString("hi, you")},
+		{text: "double(\"str\");", err: NewTypeError(NewInvalidArgumentTypeError("double", 0, TypeString, TypeNumeric), Position{1, 8})},
+		{text: "double();", err: NewTypeError(NewArityMismatchError(1, 0), Position{1, 7})},
+		{text: "double(1, 2);", err: NewTypeError(NewArityMismatchError(1, 2), Position{1, 7})},
+		{text: "greet.hello();", err: NewTypeError(NewArityMismatchError(1, 0), Position{1, 12})},
+		{text: "greet.goodbye();", err: NewTypeError(NewUndefinedPropertyError("goodbye", TypeModule), Position{1, 6})},
+		{text: "\"str\".length;", err: NewTypeError(NewUndefinedPropertyError("length", TypeString), Position{1, 6})},
+		{text: "true.length;", err: NewTypeError(NewTypeHasNoPropertiesError(TypeBoolean), Position{1, 5})},
+		{text: "1();", err: NewTypeError(NewTypeNotCallableError(TypeNumeric), Position{1, 2})},
+	}
+	for _, test := range tests {
+		val, err := interp.Eval(test.text)
+		if test.err != nil {
+			if err != test.err {
+				t.Errorf("Expected %q to produce error %q, but got %v", test.text, test.err, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("Unexpected error in %q: %s", test.text, err)
+			continue
+		}
+		if !test.val.Equals(val) {
+			t.Errorf("Expected %q to yield %s, but got %s", test.text, test.val, val)
+		}
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		text       string
+		incomplete bool
+	}{
+		{text: "", incomplete: false},
+		{text: "print 1;", incomplete: false},
+		{text: "fun f(a) {\n", incomplete: true},
+		{text: "fun f(a) {\n\treturn a;\n}\n", incomplete: false},
+		{text: "{\n\t{\n\t}\n", incomplete: true},
+		{text: "print (1 +\n", incomplete: true},
+		{text: "print (1 +\n2);\n", incomplete: false},
+		{text: "var s = \"a\n", incomplete: true},
+		{text: "var s = \"a\nb\";\n", incomplete: false},
+		{text: "var a = 1\n", incomplete: true},
+		{text: "if (true)\n", incomplete: true},
+		{text: "print 1 +\n", incomplete: true},
+		{text: "print (1 }\n", incomplete: false},
+		{text: "print 1 print 2;\n", incomplete: false},
+		{text: "1 + ;\n", incomplete: false},
+	}
+	for _, test := range tests {
+		if incomplete := Incomplete(test.text); incomplete != test.incomplete {
+			t.Errorf("Expected %q to be incomplete=%t, but got %t", test.text, test.incomplete, incomplete)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package lox
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+func newJSONModule() *Module {
+	m := NewModule("json")
+	m.RegisterNative("parse", 1, []Type{TypeString}, TypeAny, jsonParse)
+	m.RegisterNative("stringify", 1, []Type{TypeAny, TypeNumeric.Union(TypeString)}, TypeString, jsonStringify)
+	return m
+}
+
+// Decodes the JSON document into lox values
+func jsonParse(ctx *Context, args ...Value) (Value, error) {
+	src := string(args[0].(ValueString))
+	if err := ctx.memory.Allocate(len(src)); err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(strings.NewReader(src))
+	var data any
+	if err := dec.Decode(&data); err != nil {
+		return nil, NewJSONError(err)
+	}
+	if _, err := dec.Token(); err == nil {
+		return nil, NewJSONError(errors.New("unexpected data after top-level value"))
+	}
+	return FromGo(data)
+}
+
+// Encodes the value as JSON, indented by the given number of spaces or
+// string if provided
+func jsonStringify(ctx *Context, args ...Value) (Value, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if len(args) > 1 {
+		switch indent := args[1].(type) {
+		case ValueString:
+			enc.SetIndent("", string(indent))
+		case ValueNumeric:
+			n, err := integer("json.stringify", 1, indent)
+			if err != nil {
+				return nil, err
+			}
+			enc.SetIndent("", strings.Repeat(" ", max(int(n), 0)))
+		}
+	}
+	if err := enc.Encode(args[0]); err != nil {
+		var verr UnserializableValueError
+		if errors.As(err, &verr) {
+			return nil, verr
+		}
+		return nil, NewJSONError(err)
+	}
+	out := strings.TrimSuffix(buf.String(), "\n")
+	if err := ctx.memory.Allocate(len(out)); err != nil {
+		return nil, err
+	}
+	return ValueString(out), nil
+}
+
+func (v ValueNil) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+func (v ValueBoolean) MarshalJSON() ([]byte, error) {
+	return json.Marshal(bool(v))
+}
+
+func (v ValueNumeric) MarshalJSON() ([]byte, error) {
+	return json.Marshal(float64(v))
+}
+
+func (v ValueString) MarshalJSON() ([]byte, error) {
+	return marshalJSON(string(v))
+}
+
+func (v *ValueList) MarshalJSON() ([]byte, error) {
+	if v.elems == nil {
+		return []byte("[]"), nil
+	}
+	return marshalJSON(v.elems)
+}
+
+func (v *ValueMap) MarshalJSON() ([]byte, error) {
+	return marshalJSON(v.entries)
+}
+
+func (v ValueCallable) MarshalJSON() ([]byte, error) {
+	return nil, NewUnserializableValueError(v.Type())
+}
+
+func (m *Module) MarshalJSON() ([]byte, error) {
+	return nil, NewUnserializableValueError(m.Type())
+}
+
+// Like json.Marshal but leaves HTML characters unescaped
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,70 @@
+package lox
+
+import (
+	"testing"
+)
+
+func TestJSONModule(t *testing.T) {
+	interp, err := NewInterpreter(WithStdout(&PrintSpy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := makeValueMap(map[string]Value{
+		"name":  ValueString("lox"),
+		"tags":  NewValueList(ValueString("a"), ValueNumeric(1), True, Nil),
+		"inner": makeValueMap(map[string]Value{"x": ValueNumeric(1.5)}),
+	})
+	interp.SetGlobal("doc", ValueString(`{"name": "lox", "tags": ["a", 1, true, null], "inner": {"x": 1.5}}`))
+	interp.SetGlobal("nested", ValueString(`{"b": [1, "<x>"], "a": null}`))
+	interp.SetGlobal("indented", ValueString(`[1, {"a": true}]`))
+	interp.SetGlobal("trailing", ValueString(`[1] [2]`))
+	interp.SetGlobal("tab", ValueString("\t"))
+	tests := []struct {
+		text string
+		val  Value
+		err  error
+	}{
+		{text: `json.parse(doc);`, val: config},
+		{text: `json.parse(" 42 ");`, val: ValueNumeric(42)},
+		{text: `json.parse("null");`, val: Nil},
+		{text: `json.parse("[]");`, val: NewValueList()},
+		{text: `json.parse(doc).inner.x;`, val: ValueNumeric(1.5)},
+		{text: `json.stringify(json.parse(nested));`, val: ValueString(`{"a":null,"b":[1,"<x>"]}`)},
+		{text: `json.stringify(json.parse(indented), 2);`, val: ValueString("[\n  1,\n  {\n    \"a\": true\n  }\n]")},
+		{text: `json.stringify(json.parse("[1]"), tab);`, val: ValueString("[\n\t1\n]")},
+		{text: `json.stringify("é");`, val: ValueString(`"é"`)},
+		{text: `json.stringify(nil);`, val: ValueString("null")},
+		{text: `json.stringify(clock);`, err: NewRuntimeError(NewUnserializableValueError(TypeCallable), Position{1, 15})},
+		{text: `json.stringify(math);`, err: NewRuntimeError(NewUnserializableValueError(TypeModule), Position{1, 15})},
+		{text: `json.stringify(split("a", ",").upper);`, err: NewTypeError(NewTypeHasNoPropertiesError(TypeList), Position{1, 31})},
+	}
+	for _, test := range tests {
+		val, err := interp.Eval(test.text)
+		if test.err != nil {
+			if err != test.err {
+				t.Errorf("Expected %q to produce error %q, but got %v", test.text, test.err, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("Unexpected error in %q: %s", test.text, err)
+			continue
+		}
+		if !test.val.Equals(val) {
+			t.Errorf("Expected %q to yield %s, but got %s", test.text, test.val, val)
+		}
+	}
+	for _, text := range []string{`json.parse("{");`, `json.parse(trailing);`, `json.stringify(math.nan);`} {
+		if _, err := interp.Eval(text); !errorIs[JSONError](err) {
+			t.Errorf("Expected %q to produce a json error, but got %v", text, err)
+		}
+	}
+}
+
+func errorIs[T error](err error) bool {
+	rerr, ok := err.(RuntimeError)
+	if !ok {
+		return false
+	}
+	_, ok = rerr.Err.(T)
+	return ok
+}
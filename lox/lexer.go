@@ -82,6 +82,10 @@ var isNotLetterOrUnderscore = func(r rune) bool {
 	return r != '_' && !unicode.IsLetter(r)
 }
 
+var isNotLetterDigitOrUnderscore = func(r rune) bool {
+	return isNotLetterOrUnderscore(r) && !unicode.IsDigit(r)
+}
+
 func (l *Lexer) next() (*Token, error) {
 	if _, err := l.scan.until(isNotWhitespace); err != nil {
 		return nil, err
@@ -163,7 +167,7 @@ func (l *Lexer) next() (*Token, error) {
 				NewUnexpectedCharacterError("a letter or underscore character", next), token.Position,
 			)
 		} else {
-			runes, err := l.scan.until(isNotLetterOrUnderscore)
+			runes, err := l.scan.until(isNotLetterDigitOrUnderscore)
 			if err != nil && err != io.EOF {
 				return nil, err
 			}
@@ -51,6 +51,7 @@ func TestLexerBasic(t *testing.T) {
 		{"var", Token{Type: TokenVar, Lexem: "var"}},
 		{"while", Token{Type: TokenWhile, Lexem: "while"}},
 		{"foo", Token{Type: TokenIdentifier, Lexem: "foo"}},
+		{"f00_bar", Token{Type: TokenIdentifier, Lexem: "f00_bar"}},
 		{"//comment", Token{Type: TokenComment, Lexem: "comment"}},
 	}
 
@@ -0,0 +1,153 @@
+package lox
+
+import (
+	"sort"
+)
+
+var listNatives = []*BuiltinFunction{
+	NewBuiltinFunction("list", Variadic, nil, TypeList, list),
+	NewBuiltinFunction("map", 2, []Type{TypeList, TypeCallable}, TypeList, mapList),
+	NewBuiltinFunction("filter", 2, []Type{TypeList, TypeCallable}, TypeList, filterList),
+	NewBuiltinFunction("reduce", 2, []Type{TypeList, TypeCallable, TypeAny}, TypeAny, reduceList),
+	NewBuiltinFunction("sort", 1, []Type{TypeList, TypeCallable}, TypeList, sortList),
+}
+
+// Returns a list of the arguments
+func list(ctx *Context, args ...Value) (Value, error) {
+	if err := ctx.memory.Allocate(len(args) * valueSize); err != nil {
+		return nil, err
+	}
+	return NewValueList(append([]Value(nil), args...)...), nil
+}
+
+// Returns a list of the results of calling fn with each element
+func mapList(ctx *Context, args ...Value) (Value, error) {
+	elems, fn := args[0].(*ValueList).Elements(), args[1]
+	if err := ctx.memory.Allocate(len(elems) * valueSize); err != nil {
+		return nil, err
+	}
+	out := NewValueList(make([]Value, len(elems))...)
+	for i, elem := range elems {
+		val, err := callback(ctx, fn, elem)
+		if err != nil {
+			return nil, err
+		}
+		out.elems[i] = val
+	}
+	return out, nil
+}
+
+// Returns a list of the elements for which fn returns a truthy value
+func filterList(ctx *Context, args ...Value) (Value, error) {
+	elems, fn := args[0].(*ValueList).Elements(), args[1]
+	out := NewValueList()
+	for _, elem := range elems {
+		val, err := callback(ctx, fn, elem)
+		if err != nil {
+			return nil, err
+		}
+		if val.Truthy() {
+			if err := ctx.memory.Allocate(valueSize); err != nil {
+				return nil, err
+			}
+			out.Append(elem)
+		}
+	}
+	return out, nil
+}
+
+// Combines the elements from left to right by calling fn with the
+// accumulated value and each element. The accumulator starts with init if
+// given, or else the first element.
+func reduceList(ctx *Context, args ...Value) (Value, error) {
+	elems, fn := args[0].(*ValueList).Elements(), args[1]
+	var acc Value
+	if len(args) > 2 {
+		acc = args[2]
+	} else if len(elems) > 0 {
+		acc, elems = elems[0], elems[1:]
+	} else {
+		return nil, NewEmptyListError("reduce")
+	}
+	for _, elem := range elems {
+		val, err := callback(ctx, fn, acc, elem)
+		if err != nil {
+			return nil, err
+		}
+		acc = val
+	}
+	return acc, nil
+}
+
+// Returns a stably sorted copy of the list. If given, cmp(a, b) must
+// return a negative number if a precedes b, a positive number if b precedes
+// a and zero otherwise. Without it numbers and strings sort in ascending order.
+func sortList(ctx *Context, args ...Value) (Value, error) {
+	elems := args[0].(*ValueList).Elements()
+	if err := ctx.memory.Allocate(len(elems) * valueSize); err != nil {
+		return nil, err
+	}
+	out := NewValueList(append([]Value(nil), elems...)...)
+	less := func(a, b Value) (bool, error) {
+		return lessThan(a, b)
+	}
+	if len(args) > 1 {
+		cmp := args[1]
+		less = func(a, b Value) (bool, error) {
+			val, err := callback(ctx, cmp, a, b)
+			if err != nil {
+				return false, err
+			}
+			n, ok := val.(ValueNumeric)
+			if !ok {
+				return false, NewInvalidComparatorResultError(val.Type())
+			}
+			return n < 0, nil
+		}
+	}
+	var err error
+	sort.SliceStable(out.elems, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+		var ok bool
+		ok, err = less(out.elems[i], out.elems[j])
+		return ok
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Orders numbers and strings
+func lessThan(a, b Value) (bool, error) {
+	switch x := a.(type) {
+	case ValueNumeric:
+		if y, ok := b.(ValueNumeric); ok {
+			return x < y, nil
+		}
+	case ValueString:
+		if y, ok := b.(ValueString); ok {
+			return x < y, nil
+		}
+	}
+	return false, NewIncomparableValuesError(a.Type(), b.Type())
+}
+
+// Calls fn from within a native on behalf of the script. The call is
+// attributed to the native's caller in stack traces.
+func callback(ctx *Context, fn Value, args ...Value) (Value, error) {
+	call, ok := fn.(*ValueCallable)
+	if !ok {
+		return nil, NewTypeNotCallableError(fn.Type())
+	}
+	caller, _ := ctx.Caller()
+	pop := ctx.PushCall(call.name, caller.Position)
+	defer pop()
+	val, err := call.Call(ctx, args...)
+	if ret, ok := err.(ReturnErr); ok {
+		return ret.Value(), nil
+	}
+	return val, err
+}
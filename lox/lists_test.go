@@ -0,0 +1,81 @@
+package lox
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestListNatives(t *testing.T) {
+	interp, err := NewInterpreter(WithStdout(&PrintSpy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = interp.Eval(`
+		fun double(x) { return x * 2; }
+		fun odd(x) { return x - math.floor(x / 2) * 2 == 1; }
+		fun add(acc, x) { return acc + x; }
+		fun desc(a, b) { return b - a; }
+		fun byLen(a, b) { return len(a) - len(b); }
+		fun fail(x) { return x + "!"; }
+		var xs = list(3, 1, 2);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nums := func(ns ...float64) *ValueList {
+		list := NewValueList()
+		for _, n := range ns {
+			list.Append(ValueNumeric(n))
+		}
+		return list
+	}
+	tests := []struct {
+		text string
+		val  Value
+		err  error
+	}{
+		{text: "list();", val: NewValueList()},
+		{text: "xs;", val: nums(3, 1, 2)},
+		{text: "map(xs, double);", val: nums(6, 2, 4)},
+		{text: "map(xs, str);", val: stringList([]string{"3", "1", "2"})},
+		{text: "filter(xs, odd);", val: nums(3, 1)},
+		{text: "reduce(xs, add);", val: ValueNumeric(6)},
+		{text: "reduce(xs, add, 10);", val: ValueNumeric(16)},
+		{text: "reduce(list(), add, 10);", val: ValueNumeric(10)},
+		{text: "sort(xs);", val: nums(1, 2, 3)},
+		{text: "sort(xs); xs;", val: nums(3, 1, 2)},
+		{text: "sort(xs, desc);", val: nums(3, 2, 1)},
+		{text: "sort(list(\"b\", \"c\", \"a\"));", val: stringList([]string{"a", "b", "c"})},
+		{text: "sort(list(\"bb\", \"a\", \"cc\", \"d\"), byLen);", val: stringList([]string{"a", "d", "bb", "cc"})},
+		{text: "reduce(map(filter(xs, odd), double), add);", val: ValueNumeric(8)},
+		{text: "map(xs, 1);", err: NewTypeError(NewInvalidArgumentTypeError("map", 1, TypeNumeric, TypeCallable), Position{1, 9})},
+		{text: "reduce(list(), add);", err: NewRuntimeError(NewEmptyListError("reduce"), Position{1, 7})},
+		{text: "sort(list(1, \"a\"));", err: NewRuntimeError(NewIncomparableValuesError(TypeString, TypeNumeric), Position{1, 5})},
+		{text: "sort(xs, double);", err: NewRuntimeError(NewArityMismatchError(1, 2), Position{1, 5})},
+		{text: "sort(xs, odd);", err: NewRuntimeError(NewArityMismatchError(1, 2), Position{1, 5})},
+	}
+	for _, test := range tests {
+		val, err := interp.Eval(test.text)
+		if test.err != nil {
+			if err != test.err {
+				t.Errorf("Expected %q to produce error %q, but got %v", test.text, test.err, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("Unexpected error in %q: %s", test.text, err)
+			continue
+		}
+		if !test.val.Equals(val) {
+			t.Errorf("Expected %q to yield %s, but got %s", test.text, test.val, val)
+		}
+	}
+
+	_, err = interp.Eval("map(xs, fail);")
+	var rerr RuntimeError
+	if !errors.As(err, &rerr) || rerr.Trace == nil {
+		t.Fatalf("Expected a runtime error with a trace, but got %v", err)
+	}
+	if frames := rerr.Trace.Frames; len(frames) != 1 || frames[0].Function != "fail" || frames[0].Position != (Position{1, 4}) {
+		t.Errorf("Expected the callback frame to be attributed to the call to map, but got %v", frames)
+	}
+}
@@ -7,6 +7,11 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// Logging is disabled until a host enables it, such as with WithLogging
+func init() {
+	DisableLogger()
+}
+
 // Disable the logger
 func DisableLogger() {
 	zerolog.SetGlobalLevel(zerolog.Disabled)
@@ -0,0 +1,111 @@
+package lox
+
+import (
+	"math"
+)
+
+func newMathModule() *Module {
+	m := NewModule("math")
+	m.SetConstant("pi", ValueNumeric(math.Pi))
+	m.SetConstant("inf", ValueNumeric(math.Inf(1)))
+	m.SetConstant("nan", ValueNumeric(math.NaN()))
+
+	unary := map[string]func(float64) float64{
+		"floor": math.Floor,
+		"ceil":  math.Ceil,
+		"round": math.Round,
+		"abs":   math.Abs,
+		"sqrt":  math.Sqrt,
+		"sin":   math.Sin,
+		"cos":   math.Cos,
+		"tan":   math.Tan,
+		"log":   math.Log,
+		"exp":   math.Exp,
+	}
+	for name, fn := range unary {
+		fn := fn
+		m.RegisterNative(name, 1, []Type{TypeNumeric}, TypeNumeric, func(_ *Context, args ...Value) (Value, error) {
+			return ValueNumeric(fn(float64(args[0].(ValueNumeric)))), nil
+		})
+	}
+	m.RegisterNative("pow", 2, []Type{TypeNumeric, TypeNumeric}, TypeNumeric, mathPow)
+	m.RegisterNative("min", Variadic, []Type{TypeNumeric}, TypeNumeric, mathMin)
+	m.RegisterNative("max", Variadic, []Type{TypeNumeric}, TypeNumeric, mathMax)
+	m.RegisterNative("isNaN", 1, []Type{TypeNumeric}, TypeBoolean, mathIsNaN)
+	m.RegisterNative("random", 0, nil, TypeNumeric, mathRandom)
+	m.RegisterNative("randomInt", 2, []Type{TypeNumeric, TypeNumeric}, TypeNumeric, mathRandomInt)
+	m.RegisterNative("seed", 1, []Type{TypeNumeric}, TypeNil, mathSeed)
+	return m
+}
+
+func mathPow(_ *Context, args ...Value) (Value, error) {
+	x, y := args[0].(ValueNumeric), args[1].(ValueNumeric)
+	return ValueNumeric(math.Pow(float64(x), float64(y))), nil
+}
+
+func mathMin(_ *Context, args ...Value) (Value, error) {
+	return mathReduce(math.Min, args...)
+}
+
+func mathMax(_ *Context, args ...Value) (Value, error) {
+	return mathReduce(math.Max, args...)
+}
+
+func mathReduce(fn func(float64, float64) float64, args ...Value) (Value, error) {
+	if len(args) == 0 {
+		return nil, NewArityMismatchError(1, 0)
+	}
+	acc := float64(args[0].(ValueNumeric))
+	for _, arg := range args[1:] {
+		acc = fn(acc, float64(arg.(ValueNumeric)))
+	}
+	return ValueNumeric(acc), nil
+}
+
+func mathIsNaN(_ *Context, args ...Value) (Value, error) {
+	return ValueBoolean(math.IsNaN(float64(args[0].(ValueNumeric)))), nil
+}
+
+// Returns a random number in [0, 1)
+func mathRandom(ctx *Context, _ ...Value) (Value, error) {
+	return ValueNumeric(ctx.runtime.rand.Float64()), nil
+}
+
+// Returns a random integer in [lo, hi]
+func mathRandomInt(ctx *Context, args ...Value) (Value, error) {
+	lo, err := integer("math.randomInt", 0, args[0])
+	if err != nil {
+		return nil, err
+	}
+	hi, err := integer("math.randomInt", 1, args[1])
+	if err != nil {
+		return nil, err
+	}
+	// hi-lo+1 must itself be a positive int64
+	if hi < lo || hi-lo < 0 || hi-lo == math.MaxInt64 {
+		return nil, NewInvalidRangeError(float64(lo), float64(hi))
+	}
+	return ValueNumeric(lo + ctx.runtime.rand.Int63n(hi-lo+1)), nil
+}
+
+func mathSeed(ctx *Context, args ...Value) (Value, error) {
+	seed, err := integer("math.seed", 0, args[0])
+	if err != nil {
+		return nil, err
+	}
+	ctx.runtime.Seed(seed)
+	return Nil, nil
+}
+
+// Returns the i-th argument to fn as an integer, failing if it has a fractional part
+func integer(fn string, i int, arg Value) (int64, error) {
+	n, ok := arg.(ValueNumeric)
+	if !ok || float64(n) != math.Trunc(float64(n)) || math.IsInf(float64(n), 0) {
+		return 0, NewNonIntegerArgumentError(fn, i, arg)
+	}
+	// float64(math.MaxInt64) rounds up to 2^63, which is out of range
+	if float64(n) < math.MinInt64 || float64(n) >= math.MaxInt64 {
+		return 0, NewIntegerOutOfRangeError(fn, i, arg)
+	}
+	return int64(n), nil
+}
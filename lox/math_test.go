@@ -0,0 +1,86 @@
+package lox
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMathModule(t *testing.T) {
+	interp, err := NewInterpreter(WithStdout(&PrintSpy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		text string
+		val  Value
+		err  error
+	}{
+		{text: "math.floor(1.5);", val: ValueNumeric(1)},
+		{text: "math.ceil(1.5);", val: ValueNumeric(2)},
+		{text: "math.round(2.5);", val: ValueNumeric(3)},
+		{text: "math.round(-2.5);", val: ValueNumeric(-3)},
+		{text: "math.abs(-3);", val: ValueNumeric(3)},
+		{text: "math.sqrt(16);", val: ValueNumeric(4)},
+		{text: "math.pow(2, 10);", val: ValueNumeric(1024)},
+		{text: "math.min(3, 1, 2);", val: ValueNumeric(1)},
+		{text: "math.max(3, 1, 2);", val: ValueNumeric(3)},
+		{text: "math.sin(0);", val: ValueNumeric(0)},
+		{text: "math.cos(0);", val: ValueNumeric(1)},
+		{text: "math.tan(0);", val: ValueNumeric(0)},
+		{text: "math.log(1);", val: ValueNumeric(0)},
+		{text: "math.exp(0);", val: ValueNumeric(1)},
+		{text: "math.pi;", val: ValueNumeric(math.Pi)},
+		{text: "math.inf > 1000000000;", val: True},
+		{text: "math.isNaN(math.nan);", val: True},
+		{text: "math.isNaN(math.sqrt(-1));", val: True},
+		{text: "math.isNaN(1);", val: False},
+		{text: "var r = math.random(); r >= 0 and r < 1;", val: True},
+		{text: "var n = math.randomInt(1, 3); n >= 1 and n <= 3 and n == math.floor(n);", val: True},
+		{text: "math.randomInt(5, 5);", val: ValueNumeric(5)},
+		{text: "math.floor(\"1\");", err: NewTypeError(NewInvalidArgumentTypeError("math.floor", 0, TypeString, TypeNumeric), Position{1, 12})},
+		{text: "math.pow(1);", err: NewTypeError(NewArityMismatchError(2, 1), Position{1, 9})},
+		{text: "math.max(1, true);", err: NewTypeError(NewInvalidArgumentTypeError("math.max", 1, TypeBoolean, TypeNumeric), Position{1, 13})},
+		{text: "var f = math.floor; f(\"1\");", err: NewRuntimeError(NewInvalidArgumentTypeError("math.floor", 0, TypeString, TypeNumeric), Position{1, 22})},
+		{text: "var f = math.sqrt; f();", err: NewRuntimeError(NewArityMismatchError(1, 0), Position{1, 21})},
+		{text: "math.min();", err: NewRuntimeError(NewArityMismatchError(1, 0), Position{1, 9})},
+		{text: "math.randomInt(1.5, 2);", err: NewRuntimeError(NewNonIntegerArgumentError("math.randomInt", 0, ValueNumeric(1.5)), Position{1, 15})},
+		{text: "math.randomInt(2, 1);", err: NewRuntimeError(NewInvalidRangeError(2, 1), Position{1, 15})},
+		{text: "math.randomInt(-9000000000000000000, 9000000000000000000);", err: NewRuntimeError(NewInvalidRangeError(-9e18, 9e18), Position{1, 15})},
+		{text: "math.randomInt(0, 10000000000000000000);", err: NewRuntimeError(NewIntegerOutOfRangeError("math.randomInt", 1, ValueNumeric(1e19)), Position{1, 15})},
+		{text: "math.seed(-100000000000000000000);", err: NewRuntimeError(NewIntegerOutOfRangeError("math.seed", 0, ValueNumeric(-1e20)), Position{1, 10})},
+	}
+	for _, test := range tests {
+		val, err := interp.Eval(test.text)
+		if test.err != nil {
+			if err != test.err {
+				t.Errorf("Expected %q to produce error %q, but got %v", test.text, test.err, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("Unexpected error in %q: %s", test.text, err)
+			continue
+		}
+		if !test.val.Equals(val) {
+			t.Errorf("Expected %q to yield %s, but got %s", test.text, test.val, val)
+		}
+	}
+}
+
+func TestMathSeed(t *testing.T) {
+	interp, err := NewInterpreter(WithStdout(&PrintSpy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const text = "math.seed(42); math.randomInt(0, 1000000) + math.random();"
+	first, err := interp.Eval(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := interp.Eval(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.Equals(second) {
+		t.Errorf("Expected seeded sequences to match, but got %s and %s", first, second)
+	}
+}
@@ -0,0 +1,75 @@
+package lox
+
+import (
+	"sort"
+)
+
+// A named group of natives and constants, accessed from lox as name.member
+type Module struct {
+	name    string
+	members map[string]Value
+}
+
+func NewModule(name string) *Module {
+	return &Module{
+		name:    name,
+		members: make(map[string]Value),
+	}
+}
+
+func (m *Module) Name() string {
+	return m.name
+}
+
+// Adds a native function to the module (see Runtime.RegisterNative)
+func (m *Module) RegisterNative(name string, arity int, params []Type, ret Type, fn NativeFunc) {
+	qualified := m.name + "." + name
+	m.members[name] = &ValueCallable{
+		name: qualified,
+		fn:   NewBuiltinFunction(qualified, arity, params, ret, fn),
+	}
+}
+
+// Adds a constant value to the module
+func (m *Module) SetConstant(name string, val Value) {
+	m.members[name] = val
+}
+
+// Returns the member of the module with the given name, or nil
+func (m *Module) Member(name string) Value {
+	if val, ok := m.members[name]; ok {
+		return val
+	}
+	return nil
+}
+
+// Returns the names of all members in sorted order
+func (m *Module) Members() []string {
+	names := make([]string, 0, len(m.members))
+	for name := range m.members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *Module) String() string {
+	str, err := m.Print(&defaultPrinter)
+	if err != nil {
+		panic(err)
+	}
+	return str
+}
+
+func (m *Module) Type() Type {
+	return TypeModule
+}
+
+func (m *Module) Truthy() bool {
+	return true
+}
+
+func (m *Module) Equals(other Value) bool {
+	mod, ok := other.(*Module)
+	return ok && m == mod
+}
@@ -19,9 +19,16 @@ type Parser struct {
 }
 
 func NewParser(ctx *Context, tokens []Token) Parser {
+	// comments may appear between any two tokens and carry no meaning
+	significant := make([]Token, 0, len(tokens))
+	for _, token := range tokens {
+		if token.Type != TokenComment {
+			significant = append(significant, token)
+		}
+	}
 	return Parser{
 		ctx:  ctx,
-		scan: tokenScanner{tokens: tokens},
+		scan: tokenScanner{tokens: significant},
 	}
 }
 
@@ -30,7 +37,7 @@ func (p *Parser) Parse() ([]Statement, error) {
 	log.Debug().Msgf("(%s) scanning %d tokens", p.ctx.Phase(), len(p.scan.tokens))
 	stmts := make([]Statement, 0)
 	for {
-		if p.skipComments(); p.done() {
+		if p.done() {
 			log.Debug().Msgf("(%s) done", p.ctx.Phase())
 			break
 		}
@@ -41,6 +48,7 @@ func (p *Parser) Parse() ([]Statement, error) {
 				firstErr = err
 			}
 			p.synchronize()
+			continue
 		}
 		log.Debug().Msgf("(%s) statement: %s", p.ctx.Phase(), stmt.String())
 		stmts = append(stmts, stmt)
@@ -103,6 +111,7 @@ func (p *Parser) funcDeclaration(pos Position) (*FunctionDefinitionStatement, er
 			)
 		}
 		stmt.params = append(stmt.params, id.Lexem)
+		stmt.paramPos = append(stmt.paramPos, id.Position)
 		comma, ok := p.scan.match(TokenComma)
 		if ok {
 			continue
@@ -554,19 +563,27 @@ func (p *Parser) unary() (Expression, error) {
 }
 
 func (p *Parser) call() (expr Expression, err error) {
-	log.Trace().Msgf("(%s) unary expression", p.ctx.Phase())
+	log.Trace().Msgf("(%s) call expression", p.ctx.Phase())
 	expr, err = p.primary()
 	if err != nil {
 		return nil, err
 	}
 	for {
-		if lparen, ok := p.scan.match(TokenLeftParen); !ok {
-			break
-		} else {
+		if lparen, ok := p.scan.match(TokenLeftParen); ok {
 			expr, err = p.finishCall(lparen.Position, expr)
 			if err != nil {
 				return nil, err
 			}
+		} else if dot, ok := p.scan.match(TokenDot); ok {
+			id, ok := p.scan.match(TokenIdentifier)
+			if !ok {
+				return nil, NewSyntaxError(
+					NewUnexpectedTokenError(TokenIdentifier.String(), id), id.Position,
+				)
+			}
+			expr = &PropertyExpression{object: expr, name: id.Lexem, pos: dot.Position}
+		} else {
+			break
 		}
 	}
 	return expr, err
@@ -645,15 +662,6 @@ func (p *Parser) literal() (Expression, error) {
 	return nil, nil
 }
 
-func (p *Parser) skipComments() {
-	for {
-		if _, ok := p.scan.match(TokenComment); ok {
-			continue
-		}
-		break
-	}
-}
-
 func (p *Parser) grouping() (Expression, error) {
 	log.Trace().Msgf("(%s) grouping expression", p.ctx.Phase())
 	if token, ok := p.scan.match(TokenLeftParen); ok {
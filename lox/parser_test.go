@@ -52,6 +52,9 @@ func TestParserExpressionStatement(t *testing.T) {
 		{text: "foo(1, 3.14);", stmts: []ExpressionStatement{{expr: fooCallExpr(oneExpr(), piExpr())()}}},
 		{text: "foo(foo());", stmts: []ExpressionStatement{{expr: fooCallExpr(fooCallExpr()())()}}},
 		{text: "foo()();", stmts: []ExpressionStatement{{expr: makeCallExpression(fooCallExpr()())()()}}},
+		{text: "foo.bar;", stmts: []ExpressionStatement{{expr: fooPropExpr("bar")()}}},
+		{text: "foo.bar();", stmts: []ExpressionStatement{{expr: makeCallExpression(fooPropExpr("bar")())()()}}},
+		{text: "foo().bar;", stmts: []ExpressionStatement{{expr: makePropertyExpression(fooCallExpr()())("bar")()}}},
 	}
 	for _, test := range tests {
 		ctx := NewContext(&PrintSpy{})
@@ -182,6 +185,7 @@ func TestParserBlockStatement(t *testing.T) {
 		{text: "{print 1;}", stmts: []BlockStatement{{stmts: []Statement{&PrintStatement{expr: oneExpr()}}}}},
 		{text: "{1; 1;}", stmts: []BlockStatement{{stmts: []Statement{&ExpressionStatement{expr: oneExpr()}, &ExpressionStatement{expr: oneExpr()}}}}},
 		{text: "{1; {1;}}", stmts: []BlockStatement{{stmts: []Statement{&ExpressionStatement{expr: oneExpr()}, &BlockStatement{stmts: []Statement{&ExpressionStatement{expr: oneExpr()}}}}}}},
+		{text: "{//comment\n1; // comment\n}", stmts: []BlockStatement{{stmts: []Statement{&ExpressionStatement{expr: oneExpr()}}}}},
 	}
 	for _, test := range tests {
 		ctx := NewContext(&PrintSpy{})
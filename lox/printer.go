@@ -30,18 +30,6 @@ func (p *CompactPrinter) Print(elem Printable) (string, error) {
 	return elem.Print(p)
 }
 
-// type CorrectPrinter struct {
-// 	depth int
-// }
-
-// func (p *CorrectPrinter) Print(elem Printable) (string, error) {
-// 	return elem.Print(p)
-// }
-
-// func (p *CorrectPrinter) pad(indent int) string {
-// 	return strings.Repeat("\t", p.depth+indent)
-// }
-
 func (s *ConditionalStatement) Print(p Printer) (str string, err error) {
 	cond, err := s.expr.Print(p)
 	if err != nil {
@@ -270,6 +258,16 @@ func (e *CallExpression) Print(p Printer) (str string, err error) {
 	}
 	return str, err
 }
+func (e *PropertyExpression) Print(p Printer) (str string, err error) {
+	switch p.(type) {
+	case *CompactPrinter:
+		str = fmt.Sprintf("%s.%s", e.object, e.name)
+	default:
+		err = UnprintableError{e}
+	}
+	return str, err
+}
+
 func (e *StringExpression) Print(p Printer) (str string, err error) {
 	switch p.(type) {
 	case *CompactPrinter:
@@ -367,3 +365,68 @@ func (v ValueCallable) Print(p Printer) (str string, err error) {
 	}
 	return str, err
 }
+
+func (m *Module) Print(p Printer) (str string, err error) {
+	switch p.(type) {
+	case *CompactPrinter:
+		str = fmt.Sprintf("Module(%s)", m.name)
+	default:
+		err = UnprintableError{m}
+	}
+	return str, err
+}
+
+func (v *ValueList) Print(p Printer) (str string, err error) {
+	switch p.(type) {
+	case *CompactPrinter:
+		elems := make([]string, len(v.elems))
+		for i, elem := range v.elems {
+			if elems[i], err = elem.Print(p); err != nil {
+				return "", err
+			}
+		}
+		str = fmt.Sprintf("[%s]", strings.Join(elems, ", "))
+	default:
+		err = UnprintableError{v}
+	}
+	return str, err
+}
+
+func (v *ValueMap) Print(p Printer) (str string, err error) {
+	switch p.(type) {
+	case *CompactPrinter:
+		keys := v.Keys()
+		entries := make([]string, len(keys))
+		for i, key := range keys {
+			val, err := v.entries[key].Print(p)
+			if err != nil {
+				return "", err
+			}
+			entries[i] = fmt.Sprintf("%q: %s", key, val)
+		}
+		str = fmt.Sprintf("{%s}", strings.Join(entries, ", "))
+	default:
+		err = UnprintableError{v}
+	}
+	return str, err
+}
+
+func (v ValueTime) Print(p Printer) (str string, err error) {
+	switch p.(type) {
+	case *CompactPrinter:
+		str = v.t.Format(defaultTimeLayout)
+	default:
+		err = UnprintableError{v}
+	}
+	return str, err
+}
+
+func (v ValueRegexp) Print(p Printer) (str string, err error) {
+	switch p.(type) {
+	case *CompactPrinter:
+		str = fmt.Sprintf("Regexp(%s)", v.re)
+	default:
+		err = UnprintableError{v}
+	}
+	return str, err
+}
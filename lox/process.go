@@ -1,6 +1,7 @@
 package lox
 
 import (
+	"errors"
 	"fmt"
 	"os"
 )
@@ -26,11 +27,56 @@ func Exitln(code ExitCode, msg string) {
 	Exit(code)
 }
 
+// Exits with the error's status. Errors requesting an exit, such as those
+// raised by exit(), exit silently with the requested code.
 func ExitErr(err error) {
+	var exit ExitError
+	if errors.As(err, &exit) {
+		Exit(exit.Code)
+	}
+	fmt.Fprint(os.Stderr, Traceback(err))
 	fmt.Fprintf(os.Stderr, "error: %s\n", err)
 	Exit(ExitCodeErr)
 }
 
+var processNatives = []*BuiltinFunction{
+	NewBuiltinFunction("args", 0, nil, TypeList, args),
+	NewBuiltinFunction("getenv", 1, []Type{TypeString}, TypeString.Union(TypeNil), getenv),
+	NewBuiltinFunction("exit", 0, []Type{TypeNumeric}, TypeNil, exit),
+}
+
+// Returns the arguments supplied to the script
+func args(ctx *Context, _ ...Value) (Value, error) {
+	return stringList(ctx.args), nil
+}
+
+// Returns the value of the environment variable, or nil if it is unset
+func getenv(ctx *Context, args ...Value) (Value, error) {
+	name := string(args[0].(ValueString))
+	if ctx.environ == nil {
+		return nil, NewEnvironmentAccessDeniedError(name)
+	}
+	if val, ok := ctx.environ(name); ok {
+		return ValueString(val), nil
+	}
+	return Nil, nil
+}
+
+// Stops execution, exiting with the given code or 0
+func exit(_ *Context, args ...Value) (Value, error) {
+	code := int64(ExitCodeOK)
+	if len(args) > 0 {
+		var err error
+		if code, err = integer("exit", 0, args[0]); err != nil {
+			return nil, err
+		}
+	}
+	if code < 0 || code > 255 {
+		return nil, NewInvalidExitCodeError(code)
+	}
+	return nil, NewExitError(ExitCode(code))
+}
+
 // func unreachable(note string) {
 // 	panic("unreachable: " + note)
 // }
@@ -0,0 +1,64 @@
+package lox
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProcessNatives(t *testing.T) {
+	environ := map[string]string{"HOME": "/home/lox"}
+	lookup := func(name string) (string, bool) {
+		val, ok := environ[name]
+		return val, ok
+	}
+	spy := &PrintSpy{}
+	interp, err := NewInterpreter(WithStdout(spy), WithArgs("-v", "file"), WithEnviron(lookup))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		text string
+		val  Value
+		err  error
+	}{
+		{text: "args();", val: NewValueList(ValueString("-v"), ValueString("file"))},
+		{text: "getenv(\"HOME\");", val: ValueString("/home/lox")},
+		{text: "getenv(\"UNSET\");", val: Nil},
+		{text: "exit();", err: NewExitError(ExitCodeOK)},
+		{text: "exit(3);", err: NewExitError(3)},
+		{text: "fun f() { while (true) { exit(2); } } f(); print \"unreachable\";", err: NewExitError(2)},
+		{text: "exit(1.5);", err: NewNonIntegerArgumentError("exit", 0, ValueNumeric(1.5))},
+		{text: "exit(255);", err: NewExitError(255)},
+		{text: "exit(256);", err: NewRuntimeError(NewInvalidExitCodeError(256), Position{1, 5})},
+		{text: "exit(-1);", err: NewRuntimeError(NewInvalidExitCodeError(-1), Position{1, 5})},
+	}
+	for _, test := range tests {
+		val, err := interp.Eval(test.text)
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("Expected %q to produce error %q, but got %v", test.text, test.err, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("Unexpected error in %q: %s", test.text, err)
+			continue
+		}
+		if !test.val.Equals(val) {
+			t.Errorf("Expected %q to yield %s, but got %s", test.text, test.val, val)
+		}
+	}
+	if len(spy.Prints) != 0 {
+		t.Errorf("Expected execution to stop at exit, but got output %q", spy.Prints)
+	}
+
+	denied, err := NewInterpreter(WithStdout(&PrintSpy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := denied.Eval("getenv(\"HOME\");"); !errors.Is(err, NewEnvironmentAccessDeniedError("HOME")) {
+		t.Errorf("Expected getenv to be denied, but got %v", err)
+	}
+	if val, err := denied.Eval("args();"); err != nil || !val.Equals(NewValueList()) {
+		t.Errorf("Expected no args, but got %v (%v)", val, err)
+	}
+}
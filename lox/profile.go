@@ -0,0 +1,235 @@
+package lox
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Name of the outermost frame of folded stacks
+const profileRoot = "<script>"
+
+// Length beyond which statements are abbreviated in the profile table
+const profileStatementWidth = 40
+
+// Timings of a user function or of the statement at a position. Inclusive
+// time includes that of nested calls or statements, exclusive time does not.
+// Time spent in recursive calls is included only once.
+type ProfileEntry struct {
+	Name      string   // name of the function, or the statement
+	Position  Position // position of the statement
+	Count     int      // number of calls or executions
+	Inclusive time.Duration
+	Exclusive time.Duration
+	active    int // number of executions in progress
+}
+
+// An active call or statement
+type profileFrame struct {
+	entry    *ProfileEntry
+	start    time.Time
+	children time.Duration // inclusive time of nested frames
+}
+
+// Records call counts and timings of the user functions and statements
+// executed. Install it with WithHook or Context.SetHook.
+type Profiler struct {
+	clock      Clock
+	functions  map[string]*ProfileEntry
+	statements map[Position]*ProfileEntry
+	calls      []profileFrame
+	stmts      []profileFrame
+	stacks     map[string]time.Duration // exclusive time of each call stack
+	start, end time.Time                // times of the first and last events
+	called     time.Duration            // inclusive time of outermost calls
+}
+
+func NewProfiler() *Profiler {
+	return &Profiler{
+		clock:      systemClock{},
+		functions:  make(map[string]*ProfileEntry),
+		statements: make(map[Position]*ProfileEntry),
+		stacks:     make(map[string]time.Duration),
+	}
+}
+
+// Sets the clock from which timings are taken
+func (p *Profiler) SetClock(c Clock) {
+	p.clock = c
+}
+
+func (p *Profiler) BeforeStatement(ctx *Context, stmt Statement) error {
+	if !counted(stmt) {
+		return nil
+	}
+	pos := stmt.Position()
+	entry, ok := p.statements[pos]
+	if !ok {
+		name := abbreviate((&Formatter{}).header(stmt), profileStatementWidth)
+		entry = &ProfileEntry{Name: name, Position: pos}
+		p.statements[pos] = entry
+	}
+	p.stmts = p.enter(p.stmts, entry)
+	return nil
+}
+
+func (p *Profiler) AfterStatement(ctx *Context, stmt Statement, err error) {
+	if counted(stmt) {
+		p.stmts, _, _ = p.exit(p.stmts)
+	}
+}
+
+func (p *Profiler) EnterFunction(ctx *Context, name string, args []Value) error {
+	entry, ok := p.functions[name]
+	if !ok {
+		entry = &ProfileEntry{Name: name}
+		p.functions[name] = entry
+	}
+	p.calls = p.enter(p.calls, entry)
+	return nil
+}
+
+func (p *Profiler) ExitFunction(ctx *Context, name string, val Value, err error) {
+	stack := p.stack()
+	var elapsed, exclusive time.Duration
+	p.calls, elapsed, exclusive = p.exit(p.calls)
+	p.stacks[stack] += exclusive
+	if len(p.calls) == 0 {
+		p.called += elapsed
+	}
+}
+
+// Whether executions of the statement are recorded. Blocks are accounted to
+// the statements within them, and implicit returns not at all.
+func counted(stmt Statement) bool {
+	_, block := stmt.(*BlockStatement)
+	return !block && stmt.Position() != (Position{})
+}
+
+func (p *Profiler) enter(frames []profileFrame, entry *ProfileEntry) []profileFrame {
+	entry.Count += 1
+	entry.active += 1
+	return append(frames, profileFrame{entry: entry, start: p.now()})
+}
+
+func (p *Profiler) exit(frames []profileFrame) (rest []profileFrame, elapsed, exclusive time.Duration) {
+	n := len(frames) - 1
+	frame := frames[n]
+	elapsed = p.now().Sub(frame.start)
+	exclusive = elapsed - frame.children
+	frame.entry.active -= 1
+	if frame.entry.active == 0 {
+		frame.entry.Inclusive += elapsed
+	}
+	frame.entry.Exclusive += exclusive
+	if n > 0 {
+		frames[n-1].children += elapsed
+	}
+	return frames[:n], elapsed, exclusive
+}
+
+func (p *Profiler) now() time.Time {
+	now := p.clock.Now()
+	if p.start.IsZero() {
+		p.start = now
+	}
+	p.end = now
+	return now
+}
+
+// Returns the active calls as a folded stack
+func (p *Profiler) stack() string {
+	names := []string{profileRoot}
+	for _, frame := range p.calls {
+		names = append(names, frame.entry.Name)
+	}
+	return strings.Join(names, ";")
+}
+
+// Returns the timings of each function called, by descending exclusive time
+func (p *Profiler) Functions() []ProfileEntry {
+	entries := make([]ProfileEntry, 0, len(p.functions))
+	for _, entry := range p.functions {
+		entries = append(entries, *entry)
+	}
+	sortProfile(entries)
+	return entries
+}
+
+// Returns the timings of each statement executed, by descending exclusive
+// time
+func (p *Profiler) Statements() []ProfileEntry {
+	entries := make([]ProfileEntry, 0, len(p.statements))
+	for _, entry := range p.statements {
+		entries = append(entries, *entry)
+	}
+	sortProfile(entries)
+	return entries
+}
+
+func sortProfile(entries []ProfileEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Exclusive != entries[j].Exclusive {
+			return entries[i].Exclusive > entries[j].Exclusive
+		}
+		if entries[i].Position != entries[j].Position {
+			return entries[i].Position.Before(entries[j].Position)
+		}
+		return entries[i].Name < entries[j].Name
+	})
+}
+
+// Writes tables of the function and statement timings
+func (p *Profiler) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "calls\tinclusive\texclusive\t\tfunction")
+	for _, entry := range p.Functions() {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t\t%s\n", entry.Count, entry.Inclusive, entry.Exclusive, entry.Name)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(tw, "count\tinclusive\texclusive\tline:col\t\tstatement")
+	for _, entry := range p.Statements() {
+		pos := fmt.Sprintf("%d:%d", entry.Position.Line, entry.Position.Column)
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t\t%s\n", entry.Count, entry.Inclusive, entry.Exclusive, pos, entry.Name)
+	}
+	return tw.Flush()
+}
+
+// Writes the exclusive time of each call stack in nanoseconds, in the folded
+// format read by flamegraph tools. Time outside of any call is attributed to
+// the script.
+func (p *Profiler) WriteFolded(w io.Writer) error {
+	stacks := map[string]time.Duration{}
+	for stack, d := range p.stacks {
+		stacks[stack] = d
+	}
+	if !p.start.IsZero() {
+		stacks[profileRoot] += p.end.Sub(p.start) - p.called
+	}
+	names := make([]string, 0, len(stacks))
+	for stack := range stacks {
+		names = append(names, stack)
+	}
+	sort.Strings(names)
+	for _, stack := range names {
+		if _, err := fmt.Fprintf(w, "%s %d\n", stack, stacks[stack].Nanoseconds()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Shortens s to at most n runes, marking where it was cut
+func abbreviate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
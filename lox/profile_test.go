@@ -0,0 +1,104 @@
+package lox
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A clock advancing by a millisecond each time it is read
+type tickingClock struct {
+	now time.Time
+}
+
+func (c *tickingClock) Now() time.Time {
+	c.now = c.now.Add(time.Millisecond)
+	return c.now
+}
+
+func runProfiler(t *testing.T, text string) *Profiler {
+	profiler := NewProfiler()
+	profiler.SetClock(&tickingClock{})
+	interp, err := NewInterpreter(WithStdout(&PrintSpy{}), WithHook(profiler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := interp.Eval(text); err != nil {
+		t.Fatalf("Unexpected error in %q: %s", text, err)
+	}
+	return profiler
+}
+
+func TestProfiler(t *testing.T) {
+	profiler := runProfiler(t, "fun f(n) {\n\treturn n;\n}\nf(1);\nf(2);\n")
+	ms := time.Millisecond
+
+	functions := []ProfileEntry{
+		{Name: "f", Count: 2, Inclusive: 6 * ms, Exclusive: 6 * ms},
+	}
+	if got := profiler.Functions(); !reflect.DeepEqual(got, functions) {
+		t.Errorf("Expected functions %+v, but got %+v", functions, got)
+	}
+	statements := []ProfileEntry{
+		{Name: "f(1);", Position: Position{Line: 4, Column: 2}, Count: 1, Inclusive: 5 * ms, Exclusive: 4 * ms},
+		{Name: "f(2);", Position: Position{Line: 5, Column: 2}, Count: 1, Inclusive: 5 * ms, Exclusive: 4 * ms},
+		{Name: "return n;", Position: Position{Line: 2, Column: 2}, Count: 2, Inclusive: 2 * ms, Exclusive: 2 * ms},
+		{Name: "fun f(n)", Position: Position{Line: 1, Column: 1}, Count: 1, Inclusive: 1 * ms, Exclusive: 1 * ms},
+	}
+	if got := profiler.Statements(); !reflect.DeepEqual(got, statements) {
+		t.Errorf("Expected statements %+v, but got %+v", statements, got)
+	}
+
+	var folded strings.Builder
+	if err := profiler.WriteFolded(&folded); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "<script> 7000000\n<script>;f 6000000\n"; folded.String() != expected {
+		t.Errorf("Expected folded stacks %q, but got %q", expected, folded.String())
+	}
+
+	var table strings.Builder
+	if err := profiler.WriteTable(&table); err != nil {
+		t.Fatal(err)
+	}
+	var rows []string
+	for _, line := range strings.Split(table.String(), "\n") {
+		rows = append(rows, strings.Join(strings.Fields(line), " "))
+	}
+	expected := []string{
+		"calls inclusive exclusive function",
+		"2 6ms 6ms f",
+		"",
+		"count inclusive exclusive line:col statement",
+		"1 5ms 4ms 4:2 f(1);",
+		"1 5ms 4ms 5:2 f(2);",
+		"2 2ms 2ms 2:2 return n;",
+		"1 1ms 1ms 1:1 fun f(n)",
+		"",
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected table rows %q, but got %q", expected, rows)
+	}
+}
+
+func TestProfilerRecursion(t *testing.T) {
+	profiler := runProfiler(t, "fun r(n) {\n\tif (n > 0) r(n - 1);\n}\nr(2);\n")
+	functions := profiler.Functions()
+	if len(functions) != 1 || functions[0].Count != 3 {
+		t.Fatalf("Expected r to be called 3 times, but got %+v", functions)
+	}
+	// time in nested calls is counted once, and all of it is spent in r
+	if r := functions[0]; r.Inclusive != r.Exclusive {
+		t.Errorf("Expected inclusive time %s to equal exclusive time %s", r.Inclusive, r.Exclusive)
+	}
+	var folded strings.Builder
+	if err := profiler.WriteFolded(&folded); err != nil {
+		t.Fatal(err)
+	}
+	for _, stack := range []string{"<script>;r ", "<script>;r;r ", "<script>;r;r;r "} {
+		if !strings.Contains(folded.String(), stack) {
+			t.Errorf("Expected folded stacks to contain %q, but got %q", stack, folded.String())
+		}
+	}
+}
@@ -0,0 +1,414 @@
+package lox
+
+import (
+	"math"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+)
+
+var valueType = reflect.TypeOf((*Value)(nil)).Elem()
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+var contextType = reflect.TypeOf((*Context)(nil))
+var timeType = reflect.TypeOf(time.Time{})
+
+// Converts a go value to its lox equivalent.
+//   - nil and nil pointers become nil
+//   - booleans, numbers and strings become their lox counterparts
+//   - slices and arrays become lists
+//   - maps with string keys become maps
+//   - time.Time becomes a time
+//   - structs become maps of their exported fields, accessible as properties.
+//     A field's name may be overridden with a `lox:"name"` tag and "-" skips it.
+//   - funcs become callables whose arguments and results are converted
+//     automatically. A leading *Context parameter receives the caller's
+//     context and a trailing error result is returned as a runtime error.
+func FromGo(v any) (Value, error) {
+	if v == nil {
+		return Nil, nil
+	}
+	return fromGo(reflect.ValueOf(v))
+}
+
+// A pointer, map or slice being converted, by which cycles are detected
+type goReference struct {
+	typ reflect.Type
+	ptr uintptr
+}
+
+func fromGo(rv reflect.Value) (Value, error) {
+	return fromGoVisiting(rv, map[goReference]bool{})
+}
+
+// Converts rv, failing if it refers back to a value in visiting
+func fromGoVisiting(rv reflect.Value, visiting map[goReference]bool) (Value, error) {
+	if !rv.IsValid() {
+		return Nil, nil
+	}
+	switch rv.Kind() {
+	case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func:
+		if rv.IsNil() {
+			return Nil, nil
+		}
+	}
+	if rv.Type().Implements(valueType) {
+		return rv.Interface().(Value), nil
+	}
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		ref := goReference{typ: rv.Type(), ptr: rv.Pointer()}
+		if visiting[ref] {
+			return nil, NewConversionError("cyclic "+rv.Type().String(), "lox value")
+		}
+		visiting[ref] = true
+		defer delete(visiting, ref)
+	}
+	if rv.Type() == timeType {
+		return NewValueTime(rv.Interface().(time.Time)), nil
+	}
+	switch rv.Kind() {
+	case reflect.Bool:
+		return ValueBoolean(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return ValueNumeric(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return ValueNumeric(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return ValueNumeric(rv.Float()), nil
+	case reflect.String:
+		return ValueString(rv.String()), nil
+	case reflect.Pointer, reflect.Interface:
+		return fromGoVisiting(rv.Elem(), visiting)
+	case reflect.Slice, reflect.Array:
+		list := NewValueList(make([]Value, rv.Len())...)
+		for i := 0; i < rv.Len(); i++ {
+			elem, err := fromGoVisiting(rv.Index(i), visiting)
+			if err != nil {
+				return nil, err
+			}
+			list.elems[i] = elem
+		}
+		return list, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, NewConversionError(rv.Type().String(), TypeMap.String())
+		}
+		m := NewValueMap()
+		iter := rv.MapRange()
+		for iter.Next() {
+			val, err := fromGoVisiting(iter.Value(), visiting)
+			if err != nil {
+				return nil, err
+			}
+			m.Set(iter.Key().String(), val)
+		}
+		return m, nil
+	case reflect.Struct:
+		m := NewValueMap()
+		typ := rv.Type()
+		for i := 0; i < typ.NumField(); i++ {
+			name, ok := fieldName(typ.Field(i))
+			if !ok {
+				continue
+			}
+			val, err := fromGoVisiting(rv.Field(i), visiting)
+			if err != nil {
+				return nil, err
+			}
+			m.Set(name, val)
+		}
+		return m, nil
+	case reflect.Func:
+		fn, err := nativeFromGo(rv)
+		if err != nil {
+			return nil, err
+		}
+		return &ValueCallable{name: fn.name, fn: fn}, nil
+	}
+	return nil, NewConversionError(rv.Type().String(), "lox value")
+}
+
+// Converts a lox value to a go value of the given type (see FromGo).
+// Converting to an interface type yields the natural go representation:
+// bool, float64, string, []any or map[string]any.
+func ToGo(v Value, typ reflect.Type) (any, error) {
+	rv, err := toGo(v, typ)
+	if err != nil {
+		return nil, err
+	}
+	return rv.Interface(), nil
+}
+
+func toGo(v Value, typ reflect.Type) (reflect.Value, error) {
+	if v == nil {
+		v = Nil
+	}
+	natural := typ.Kind() == reflect.Interface && typ.NumMethod() == 0
+	if !natural && reflect.TypeOf(v).AssignableTo(typ) {
+		return reflect.ValueOf(v).Convert(typ), nil
+	}
+	fail := func() (reflect.Value, error) {
+		return reflect.Value{}, NewConversionError(v.Type().String(), typ.String())
+	}
+	if _, ok := v.(ValueNil); ok {
+		switch typ.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func:
+			return reflect.Zero(typ), nil
+		}
+		return fail()
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		if b, ok := v.(ValueBoolean); ok {
+			return reflect.ValueOf(bool(b)).Convert(typ), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n, ok := v.(ValueNumeric); ok && float64(n) == math.Trunc(float64(n)) {
+			out := reflect.New(typ).Elem()
+			// converting an out of range float is implementation-defined, so
+			// the bounds are checked first; float64(math.MaxInt64) and
+			// float64(math.MaxUint64) round up out of range
+			if typ.Kind() >= reflect.Uint {
+				if n < 0 || float64(n) >= math.MaxUint64 || out.OverflowUint(uint64(n)) {
+					return fail()
+				}
+				out.SetUint(uint64(n))
+			} else {
+				if float64(n) < math.MinInt64 || float64(n) >= math.MaxInt64 || out.OverflowInt(int64(n)) {
+					return fail()
+				}
+				out.SetInt(int64(n))
+			}
+			return out, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, ok := v.(ValueNumeric); ok {
+			return reflect.ValueOf(float64(n)).Convert(typ), nil
+		}
+	case reflect.String:
+		if s, ok := v.(ValueString); ok {
+			return reflect.ValueOf(string(s)).Convert(typ), nil
+		}
+	case reflect.Pointer:
+		elem, err := toGo(v, typ.Elem())
+		if err != nil {
+			return fail()
+		}
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	case reflect.Slice, reflect.Array:
+		list, ok := v.(*ValueList)
+		if !ok {
+			break
+		}
+		var out reflect.Value
+		if typ.Kind() == reflect.Slice {
+			out = reflect.MakeSlice(typ, list.Len(), list.Len())
+		} else if list.Len() == typ.Len() {
+			out = reflect.New(typ).Elem()
+		} else {
+			break
+		}
+		for i, elem := range list.elems {
+			rv, err := toGo(elem, typ.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			out.Index(i).Set(rv)
+		}
+		return out, nil
+	case reflect.Map:
+		m, ok := v.(*ValueMap)
+		if !ok || typ.Key().Kind() != reflect.String {
+			break
+		}
+		out := reflect.MakeMapWithSize(typ, m.Len())
+		for key, val := range m.entries {
+			rv, err := toGo(val, typ.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			out.SetMapIndex(reflect.ValueOf(key).Convert(typ.Key()), rv)
+		}
+		return out, nil
+	case reflect.Struct:
+		if t, ok := v.(ValueTime); ok && typ == timeType {
+			return reflect.ValueOf(t.t), nil
+		}
+		m, ok := v.(*ValueMap)
+		if !ok {
+			break
+		}
+		out := reflect.New(typ).Elem()
+		for i := 0; i < typ.NumField(); i++ {
+			name, ok := fieldName(typ.Field(i))
+			if !ok {
+				continue
+			}
+			val, ok := m.Get(name)
+			if !ok {
+				continue
+			}
+			rv, err := toGo(val, typ.Field(i).Type)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			out.Field(i).Set(rv)
+		}
+		return out, nil
+	case reflect.Interface:
+		if !natural {
+			break
+		}
+		var ntyp reflect.Type
+		switch v.(type) {
+		case ValueBoolean:
+			ntyp = reflect.TypeOf(false)
+		case ValueNumeric:
+			ntyp = reflect.TypeOf(float64(0))
+		case ValueString:
+			ntyp = reflect.TypeOf("")
+		case *ValueList:
+			ntyp = reflect.TypeOf([]any{})
+		case *ValueMap:
+			ntyp = reflect.TypeOf(map[string]any{})
+		case ValueTime:
+			ntyp = timeType
+		default:
+			return reflect.ValueOf(v).Convert(typ), nil
+		}
+		rv, err := toGo(v, ntyp)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return rv.Convert(typ), nil
+	}
+	return fail()
+}
+
+// Wraps a go func as a native function
+func nativeFromGo(fn reflect.Value) (*BuiltinFunction, error) {
+	typ := fn.Type()
+	name := "<go func>"
+	if f := runtime.FuncForPC(fn.Pointer()); f != nil {
+		name = f.Name()[strings.LastIndex(f.Name(), ".")+1:]
+	}
+
+	offset := 0
+	if typ.NumIn() > 0 && typ.In(0) == contextType {
+		offset = 1
+	}
+	nout := typ.NumOut()
+	returnsErr := nout > 0 && typ.Out(nout-1) == errorType
+	if returnsErr {
+		nout -= 1
+	}
+	if nout > 1 {
+		return nil, NewConversionError(typ.String(), TypeCallable.String())
+	}
+
+	params := make([]Type, typ.NumIn()-offset)
+	for i := range params {
+		params[i] = typeForGo(typ.In(i + offset))
+	}
+	arity := len(params)
+	if typ.IsVariadic() {
+		arity = Variadic
+		if len(params) > 1 {
+			// only the type of the variadic parameter can be described
+			params = nil
+		} else {
+			params = []Type{typeForGo(typ.In(typ.NumIn() - 1).Elem())}
+		}
+	}
+	ret := TypeNil
+	if nout == 1 {
+		ret = typeForGo(typ.Out(0))
+	}
+
+	exec := func(ctx *Context, args ...Value) (Value, error) {
+		nparams := typ.NumIn() - offset
+		if typ.IsVariadic() && len(args) < nparams-1 || !typ.IsVariadic() && len(args) != nparams {
+			return nil, NewArityMismatchError(nparams, len(args))
+		}
+		in := make([]reflect.Value, 0, len(args)+offset)
+		if offset > 0 {
+			in = append(in, reflect.ValueOf(ctx))
+		}
+		for i, arg := range args {
+			var ptyp reflect.Type
+			if typ.IsVariadic() && i >= nparams-1 {
+				ptyp = typ.In(typ.NumIn() - 1).Elem()
+			} else {
+				ptyp = typ.In(i + offset)
+			}
+			rv, err := toGo(arg, ptyp)
+			if err != nil {
+				return nil, NewInvalidArgumentTypeError(name, i, arg.Type(), typeForGo(ptyp))
+			}
+			in = append(in, rv)
+		}
+		out := fn.Call(in)
+		if returnsErr {
+			if err := out[len(out)-1]; !err.IsNil() {
+				return nil, err.Interface().(error)
+			}
+		}
+		if nout == 0 {
+			return Nil, nil
+		}
+		return fromGo(out[0])
+	}
+	return NewBuiltinFunction(name, arity, params, ret, exec), nil
+}
+
+// Returns the lox type corresponding to the go type
+func typeForGo(typ reflect.Type) Type {
+	if typ.Implements(valueType) {
+		return TypeAny
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return TypeBoolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return TypeNumeric
+	case reflect.String:
+		return TypeString
+	case reflect.Slice:
+		return TypeList.Union(TypeNil)
+	case reflect.Array:
+		return TypeList
+	case reflect.Map:
+		return TypeMap.Union(TypeNil)
+	case reflect.Struct:
+		if typ == timeType {
+			return TypeTime
+		}
+		return TypeMap
+	case reflect.Func:
+		return TypeCallable.Union(TypeNil)
+	case reflect.Pointer:
+		return typeForGo(typ.Elem()).Union(TypeNil)
+	}
+	return TypeAny
+}
+
+// Returns the lox name of the struct field, or false if it is not exposed
+func fieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	tag := field.Tag.Get("lox")
+	if tag == "-" {
+		return "", false
+	}
+	if tag != "" {
+		return tag, true
+	}
+	return field.Name, true
+}
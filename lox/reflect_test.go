@@ -0,0 +1,212 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+type reflectPoint struct {
+	X, Y   float64
+	Label  string `lox:"label"`
+	hidden int
+	Skip   bool `lox:"-"`
+}
+
+type reflectNode struct {
+	Next *reflectNode
+}
+
+func TestFromGo(t *testing.T) {
+	var nilPtr *reflectPoint
+	shared := &reflectNode{}
+	tests := []struct {
+		in  any
+		val Value
+	}{
+		{in: nil, val: Nil},
+		{in: true, val: True},
+		{in: 42, val: ValueNumeric(42)},
+		{in: uint8(7), val: ValueNumeric(7)},
+		{in: 3.14, val: ValueNumeric(3.14)},
+		{in: "str", val: ValueString("str")},
+		{in: nilPtr, val: Nil},
+		{in: ValueString("lox"), val: ValueString("lox")},
+		{in: struct{ V Value }{}, val: makeValueMap(map[string]Value{"V": Nil})},
+		{in: struct{ F func() }{}, val: makeValueMap(map[string]Value{"F": Nil})},
+		{in: map[int]int(nil), val: Nil},
+		{in: []*reflectNode{shared, shared}, val: NewValueList(
+			makeValueMap(map[string]Value{"Next": Nil}), makeValueMap(map[string]Value{"Next": Nil}),
+		)},
+		{in: []int{1, 2}, val: NewValueList(ValueNumeric(1), ValueNumeric(2))},
+		{in: [2]string{"a", "b"}, val: NewValueList(ValueString("a"), ValueString("b"))},
+		{in: map[string]any{"a": 1, "b": []any{"c"}}, val: makeValueMap(map[string]Value{
+			"a": ValueNumeric(1), "b": NewValueList(ValueString("c")),
+		})},
+		{in: reflectPoint{X: 1, Y: 2, Label: "p"}, val: makeValueMap(map[string]Value{
+			"X": ValueNumeric(1), "Y": ValueNumeric(2), "label": ValueString("p"),
+		})},
+		{in: &reflectPoint{X: 3}, val: makeValueMap(map[string]Value{
+			"X": ValueNumeric(3), "Y": ValueNumeric(0), "label": ValueString(""),
+		})},
+	}
+	for _, test := range tests {
+		val, err := FromGo(test.in)
+		if err != nil {
+			t.Errorf("Unexpected error converting %#v: %s", test.in, err)
+			continue
+		}
+		if !test.val.Equals(val) {
+			t.Errorf("Expected %#v to convert to %s, but got %s", test.in, test.val, val)
+		}
+	}
+	cyclic := &reflectNode{}
+	cyclic.Next = cyclic
+	cyclicMap := map[string]any{}
+	cyclicMap["self"] = cyclicMap
+	cyclicSlice := []any{nil}
+	cyclicSlice[0] = cyclicSlice
+	for _, in := range []any{map[int]int{1: 1}, make(chan int), complex(1, 2), cyclic, cyclicMap, cyclicSlice} {
+		if _, err := FromGo(in); !errors.As(err, &ConversionError{}) {
+			t.Errorf("Expected converting %#v to fail with a conversion error, but got %v", in, err)
+		}
+	}
+}
+
+func TestToGo(t *testing.T) {
+	tests := []struct {
+		val Value
+		typ reflect.Type
+		out any
+		err bool
+	}{
+		{val: True, typ: reflect.TypeOf(false), out: true},
+		{val: ValueNumeric(42), typ: reflect.TypeOf(0), out: 42},
+		{val: ValueNumeric(42), typ: reflect.TypeOf(uint16(0)), out: uint16(42)},
+		{val: ValueNumeric(1.5), typ: reflect.TypeOf(float32(0)), out: float32(1.5)},
+		{val: ValueString("str"), typ: reflect.TypeOf(""), out: "str"},
+		{val: Nil, typ: reflect.TypeOf((*int)(nil)), out: (*int)(nil)},
+		{val: nil, typ: reflect.TypeOf((*int)(nil)), out: (*int)(nil)},
+		{val: nil, typ: valueType, out: Nil},
+		{val: ValueNumeric(1), typ: valueType, out: ValueNumeric(1)},
+		{val: NewValueList(ValueNumeric(1), ValueNumeric(2)), typ: reflect.TypeOf([]int{}), out: []int{1, 2}},
+		{val: NewValueList(ValueString("a"), True), typ: reflect.TypeOf((*any)(nil)).Elem(), out: []any{"a", true}},
+		{
+			val: makeValueMap(map[string]Value{"a": ValueNumeric(1)}),
+			typ: reflect.TypeOf(map[string]float64{}),
+			out: map[string]float64{"a": 1},
+		},
+		{
+			val: makeValueMap(map[string]Value{"X": ValueNumeric(1), "label": ValueString("p"), "extra": Nil}),
+			typ: reflect.TypeOf(reflectPoint{}),
+			out: reflectPoint{X: 1, Label: "p"},
+		},
+		{val: ValueNumeric(1.5), typ: reflect.TypeOf(0), err: true},
+		{val: ValueNumeric(-1), typ: reflect.TypeOf(uint(0)), err: true},
+		{val: ValueNumeric(300), typ: reflect.TypeOf(int8(0)), err: true},
+		{val: ValueNumeric(1e19), typ: reflect.TypeOf(int64(0)), err: true},
+		{val: ValueNumeric(9.3e18), typ: reflect.TypeOf(int64(0)), err: true},
+		{val: ValueNumeric(-9.3e18), typ: reflect.TypeOf(int64(0)), err: true},
+		{val: ValueNumeric(math.Inf(1)), typ: reflect.TypeOf(int64(0)), err: true},
+		{val: ValueNumeric(math.Inf(-1)), typ: reflect.TypeOf(int64(0)), err: true},
+		{val: ValueNumeric(math.Inf(1)), typ: reflect.TypeOf(uint64(0)), err: true},
+		{val: ValueNumeric(math.Inf(-1)), typ: reflect.TypeOf(uint64(0)), err: true},
+		{val: ValueNumeric(2e19), typ: reflect.TypeOf(uint64(0)), err: true},
+		{val: ValueNumeric(-1), typ: reflect.TypeOf(uint64(0)), err: true},
+		{val: ValueNumeric(-1), typ: reflect.TypeOf(uint8(0)), err: true},
+		{val: ValueNumeric(1e19), typ: reflect.TypeOf(uint64(0)), out: uint64(1e19)},
+		{val: ValueNumeric(-9e18), typ: reflect.TypeOf(int64(0)), out: int64(-9e18)},
+		{val: ValueString("1"), typ: reflect.TypeOf(0), err: true},
+		{val: Nil, typ: reflect.TypeOf(""), err: true},
+		{val: NewValueList(ValueString("a")), typ: reflect.TypeOf([]int{}), err: true},
+	}
+	for _, test := range tests {
+		out, err := ToGo(test.val, test.typ)
+		if test.err {
+			if !errors.As(err, &ConversionError{}) {
+				t.Errorf("Expected converting %s to %s to fail, but got %#v (%v)", test.val, test.typ, out, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("Unexpected error converting %s to %s: %s", test.val, test.typ, err)
+			continue
+		}
+		if !reflect.DeepEqual(out, test.out) {
+			t.Errorf("Expected %s to convert to %#v, but got %#v", test.val, test.out, out)
+		}
+	}
+}
+
+func TestFromGoFunc(t *testing.T) {
+	interp, err := NewInterpreter(WithStdout(&PrintSpy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	funcs := map[string]any{
+		"add": func(a, b int) int { return a + b },
+		"join": func(sep string, parts ...string) string {
+			out := ""
+			for i, part := range parts {
+				if i > 0 {
+					out += sep
+				}
+				out += part
+			}
+			return out
+		},
+		"fail":   func() error { return fmt.Errorf("failed") },
+		"point":  func(x float64) reflectPoint { return reflectPoint{X: x, Label: "p"} },
+		"phase":  func(ctx *Context) string { return ctx.Phase().String() },
+		"length": func(xs []float64) int { return len(xs) },
+		"none":   func() Value { return nil },
+	}
+	for name, fn := range funcs {
+		val, err := FromGo(fn)
+		if err != nil {
+			t.Fatalf("Unexpected error converting %s: %s", name, err)
+		}
+		interp.SetGlobal(name, val)
+	}
+	tests := []struct {
+		text string
+		val  Value
+		err  bool
+	}{
+		{text: "add(1, 2);", val: ValueNumeric(3)},
+		{text: "join(\"-\", \"a\", \"b\", \"c\");", val: ValueString("a-b-c")},
+		{text: "point(2).X;", val: ValueNumeric(2)},
+		{text: "point(2).label;", val: ValueString("p")},
+		{text: "phase();", val: ValueString("execute")},
+		{text: "none();", val: Nil},
+		{text: "length(point(1));", err: true},
+		{text: "add(1.5, 2);", err: true},
+		{text: "add(1);", err: true},
+		{text: "fail();", err: true},
+		{text: "point(1).missing;", err: true},
+	}
+	for _, test := range tests {
+		val, err := interp.Eval(test.text)
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected %q to fail, but got %s", test.text, val)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("Unexpected error in %q: %s", test.text, err)
+			continue
+		}
+		if !test.val.Equals(val) {
+			t.Errorf("Expected %q to yield %s, but got %s", test.text, test.val, val)
+		}
+	}
+}
+
+func makeValueMap(entries map[string]Value) *ValueMap {
+	m := NewValueMap()
+	for key, val := range entries {
+		m.Set(key, val)
+	}
+	return m
+}
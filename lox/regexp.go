@@ -0,0 +1,145 @@
+package lox
+
+import (
+	"regexp"
+)
+
+// Type of the pattern argument accepted by the re module, either a
+// compiled regexp or a string which is compiled on demand
+var patternType = TypeRegexp.Union(TypeString)
+
+func newRegexpModule() *Module {
+	m := NewModule("re")
+	m.RegisterNative("compile", 1, []Type{TypeString}, TypeRegexp, reCompile)
+	m.RegisterNative("match", 2, []Type{patternType, TypeString}, TypeList.Union(TypeNil), reMatch)
+	m.RegisterNative("findAll", 2, []Type{patternType, TypeString}, TypeList, reFindAll)
+	m.RegisterNative("replace", 3, []Type{patternType, TypeString, TypeString}, TypeString, reReplace)
+	m.RegisterNative("split", 2, []Type{patternType, TypeString}, TypeList, reSplit)
+	return m
+}
+
+// A compiled regular expression, opaque to lox
+type ValueRegexp struct {
+	re *regexp.Regexp
+}
+
+func (v ValueRegexp) String() string {
+	str, err := v.Print(&defaultPrinter)
+	if err != nil {
+		panic(err)
+	}
+	return str
+}
+
+func (ValueRegexp) Type() Type {
+	return TypeRegexp
+}
+
+func (v ValueRegexp) Truthy() bool {
+	return true
+}
+
+func (v ValueRegexp) Equals(other Value) bool {
+	re, ok := other.(ValueRegexp)
+	return ok && v.re.String() == re.re.String()
+}
+
+func (v ValueRegexp) MarshalJSON() ([]byte, error) {
+	return nil, NewUnserializableValueError(v.Type())
+}
+
+func reCompile(_ *Context, args ...Value) (Value, error) {
+	re, err := pattern(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return ValueRegexp{re: re}, nil
+}
+
+// Returns the first match and its submatches, or nil if there is none.
+// Unmatched optional groups are nil.
+func reMatch(ctx *Context, args ...Value) (Value, error) {
+	re, err := pattern(args[0])
+	if err != nil {
+		return nil, err
+	}
+	s := string(args[1].(ValueString))
+	loc := re.FindStringSubmatchIndex(s)
+	if loc == nil {
+		return Nil, nil
+	}
+	if err := ctx.memory.Allocate(len(loc) / 2 * valueSize); err != nil {
+		return nil, err
+	}
+	list := NewValueList(make([]Value, len(loc)/2)...)
+	for i := range list.elems {
+		if loc[2*i] < 0 {
+			list.elems[i] = Nil
+		} else {
+			list.elems[i] = ValueString(s[loc[2*i]:loc[2*i+1]])
+		}
+	}
+	return list, nil
+}
+
+// Returns all successive non-overlapping matches
+func reFindAll(ctx *Context, args ...Value) (Value, error) {
+	re, err := pattern(args[0])
+	if err != nil {
+		return nil, err
+	}
+	matches := re.FindAllString(string(args[1].(ValueString)), -1)
+	if err := ctx.memory.Allocate(len(matches) * valueSize); err != nil {
+		return nil, err
+	}
+	return stringList(matches), nil
+}
+
+// Replaces all matches, expanding $1 or ${name} in the replacement
+func reReplace(ctx *Context, args ...Value) (Value, error) {
+	re, err := pattern(args[0])
+	if err != nil {
+		return nil, err
+	}
+	s, repl := string(args[1].(ValueString)), string(args[2].(ValueString))
+	out := re.ReplaceAllString(s, repl)
+	if err := ctx.memory.Allocate(len(out)); err != nil {
+		return nil, err
+	}
+	return ValueString(out), nil
+}
+
+func reSplit(ctx *Context, args ...Value) (Value, error) {
+	re, err := pattern(args[0])
+	if err != nil {
+		return nil, err
+	}
+	parts := re.Split(string(args[1].(ValueString)), -1)
+	if err := ctx.memory.Allocate(len(parts) * valueSize); err != nil {
+		return nil, err
+	}
+	return stringList(parts), nil
+}
+
+// Returns the regexp given by a pattern argument
+func pattern(arg Value) (*regexp.Regexp, error) {
+	switch p := arg.(type) {
+	case ValueRegexp:
+		return p.re, nil
+	case ValueString:
+		re, err := regexp.Compile(string(p))
+		if err != nil {
+			return nil, NewInvalidPatternError(string(p), err)
+		}
+		return re, nil
+	}
+	return nil, NewInvalidArgumentTypeError("re", 0, arg.Type(), patternType)
+}
+
+func stringList(strs []string) *ValueList {
+	list := NewValueList(make([]Value, len(strs))...)
+	for i, s := range strs {
+		list.elems[i] = ValueString(s)
+	}
+	return list
+}
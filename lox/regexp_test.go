@@ -0,0 +1,53 @@
+package lox
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRegexpModule(t *testing.T) {
+	interp, err := NewInterpreter(WithStdout(&PrintSpy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := func(strs ...string) *ValueList {
+		return stringList(strs)
+	}
+	tests := []struct {
+		text string
+		val  Value
+		err  error
+	}{
+		{text: `var date = re.compile("(\d+)-(\d+)(-(\d+))?"); date;`, val: ValueRegexp{re: regexp.MustCompile(`(\d+)-(\d+)(-(\d+))?`)}},
+		{text: `re.match(date, "on 2024-03 at");`, val: NewValueList(ValueString("2024-03"), ValueString("2024"), ValueString("03"), Nil, Nil)},
+		{text: `re.match(date, "never");`, val: Nil},
+		{text: `re.match("^(\w+)=", "level=info msg");`, val: list("level=", "level")},
+		{text: `re.findAll("\d+", "a1b22c333");`, val: list("1", "22", "333")},
+		{text: `re.findAll(date, "none");`, val: list()},
+		{text: `re.replace(date, "2024-03-09", "$3/$2/$1");`, val: ValueString("-09/03/2024")},
+		{text: `re.replace("(?P<key>\w+)=(?P<val>\w+)", "a=b c=d", "${val}=${key}");`, val: ValueString("b=a d=c")},
+		{text: `re.split("\s*,\s*", "a , b,c");`, val: list("a", "b", "c")},
+		{text: `str(date);`, val: ValueString(`Regexp((\d+)-(\d+)(-(\d+))?)`)},
+		{text: `re.split(1, "a");`, err: NewTypeError(NewInvalidArgumentTypeError("re.split", 0, TypeNumeric, patternType), Position{1, 10})},
+	}
+	for _, test := range tests {
+		val, err := interp.Eval(test.text)
+		if test.err != nil {
+			if err != test.err {
+				t.Errorf("Expected %q to produce error %q, but got %v", test.text, test.err, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("Unexpected error in %q: %s", test.text, err)
+			continue
+		}
+		if !test.val.Equals(val) {
+			t.Errorf("Expected %q to yield %s, but got %s", test.text, test.val, val)
+		}
+	}
+	for _, text := range []string{`re.compile("(");`, `re.findAll("[", "x");`} {
+		if _, err := interp.Eval(text); !errorIs[InvalidPatternError](err) {
+			t.Errorf("Expected %q to produce an invalid pattern error, but got %v", text, err)
+		}
+	}
+}
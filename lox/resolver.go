@@ -4,9 +4,12 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// Binds each variable reference in the program to the declaration it refers
+// to. The bindings are available from the context afterwards.
 func Resolve(ctx *Context, stmts []Statement) error {
 	restore := ctx.StartPhase(PhaseResolve)
 	defer restore()
+	ctx.bindings = newBindings()
 	for _, stmt := range stmts {
 		log.Debug().Msgf("(%s) resolving %s", ctx.Phase(), stmt)
 		if err := stmt.Resolve(ctx); err != nil {
@@ -14,74 +17,252 @@ func Resolve(ctx *Context, stmts []Statement) error {
 			return err
 		}
 	}
+	ctx.bindings.resolveGlobals()
 	return nil
 }
 
+type BindingKind int
+
+const (
+	BindingVariable BindingKind = iota
+	BindingFunction
+	BindingParameter
+	// names provided by the runtime rather than declared in the source
+	BindingNative
+	BindingModule
+	BindingMember
+)
+
+func (k BindingKind) String() string {
+	switch k {
+	case BindingFunction:
+		return "function"
+	case BindingParameter:
+		return "parameter"
+	case BindingNative:
+		return "native"
+	case BindingModule:
+		return "module"
+	case BindingMember:
+		return "member"
+	}
+	return "variable"
+}
+
+// A name declared in the source and the places it is referenced
+type Binding struct {
+	Name       string
+	Kind       BindingKind
+	Position   Position   // position of the declaring statement
+	Depth      int        // depth of the declaring scope, zero being global
+	References []Position // positions of the references, in source order
+}
+
+// Bindings of the names in a program established by Resolve
+type Bindings struct {
+	declarations []*Binding
+	refs         map[Position]*Binding
+	scopes       []map[string]*Binding
+	unresolved   []reference // references to names not yet declared
+}
+
+type reference struct {
+	name string
+	pos  Position
+}
+
+func newBindings() *Bindings {
+	return &Bindings{
+		refs:   make(map[Position]*Binding),
+		scopes: []map[string]*Binding{make(map[string]*Binding)},
+	}
+}
+
+// Returns the declarations in source order
+func (b *Bindings) Declarations() []*Binding {
+	return b.declarations
+}
+
+// Returns the binding referenced at pos, or nil if the reference is to a
+// global native or is undefined
+func (b *Bindings) Reference(pos Position) *Binding {
+	return b.refs[pos]
+}
+
+func (b *Bindings) push() (pop func()) {
+	b.scopes = append(b.scopes, make(map[string]*Binding))
+	return func() {
+		b.scopes = b.scopes[:len(b.scopes)-1]
+	}
+}
+
+func (b *Bindings) declare(name string, kind BindingKind, pos Position) {
+	binding := &Binding{Name: name, Kind: kind, Position: pos, Depth: len(b.scopes) - 1}
+	b.scopes[len(b.scopes)-1][name] = binding
+	b.declarations = append(b.declarations, binding)
+}
+
+func (b *Bindings) reference(name string, pos Position) {
+	for i := len(b.scopes) - 1; i >= 0; i-- {
+		if binding, ok := b.scopes[i][name]; ok {
+			b.bind(binding, pos)
+			return
+		}
+	}
+	b.unresolved = append(b.unresolved, reference{name: name, pos: pos})
+}
+
+func (b *Bindings) bind(binding *Binding, pos Position) {
+	binding.References = append(binding.References, pos)
+	b.refs[pos] = binding
+}
+
+// Binds references preceding the declaration of the global they refer to,
+// as occurs in functions referring to later declarations
+func (b *Bindings) resolveGlobals() {
+	for _, ref := range b.unresolved {
+		if binding, ok := b.scopes[0][ref.name]; ok {
+			b.bind(binding, ref.pos)
+		}
+	}
+	b.unresolved = nil
+}
+
 func (s *BlockStatement) Resolve(ctx *Context) error {
-	// TODO: block introduces new scope
+	pop := ctx.bindings.push()
+	defer pop()
+	for _, stmt := range s.stmts {
+		if err := stmt.Resolve(ctx); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func (s *FunctionDefinitionStatement) Resolve(ctx *Context) error {
-	// TODO: fundef introduces new scope for body and binds params within scope
+	ctx.bindings.declare(s.name, BindingFunction, s.pos)
+	pop := ctx.bindings.push()
+	defer pop()
+	for i, param := range s.params {
+		pos := s.pos
+		if i < len(s.paramPos) {
+			pos = s.paramPos[i]
+		}
+		ctx.bindings.declare(param, BindingParameter, pos)
+	}
+	for _, stmt := range s.body {
+		if err := stmt.Resolve(ctx); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func (s *DeclarationStatement) Resolve(ctx *Context) error {
-	// TODO: adds var to scope
+	if err := s.expr.Resolve(ctx); err != nil {
+		return err
+	}
+	ctx.bindings.declare(s.name, BindingVariable, s.pos)
 	return nil
 }
 
 func (e *AssignmentExpression) Resolve(ctx *Context) error {
-	// TODO: resolve var
+	if err := e.right.Resolve(ctx); err != nil {
+		return err
+	}
+	ctx.bindings.reference(e.name, e.pos)
 	return nil
 }
 
 func (e *VariableExpression) Resolve(ctx *Context) error {
-	// TODO: resolve var
+	ctx.bindings.reference(e.name, e.pos)
 	return nil
 }
 
 func (s *ConditionalStatement) Resolve(ctx *Context) error {
+	if err := s.expr.Resolve(ctx); err != nil {
+		return err
+	}
+	if err := s.thenBranch.Resolve(ctx); err != nil {
+		return err
+	}
+	if s.elseBranch != nil {
+		return s.elseBranch.Resolve(ctx)
+	}
 	return nil
 }
 
 func (s *WhileStatement) Resolve(ctx *Context) error {
-	return nil
+	if err := s.expr.Resolve(ctx); err != nil {
+		return err
+	}
+	return s.body.Resolve(ctx)
 }
 
 func (s *ForStatement) Resolve(ctx *Context) error {
+	pop := ctx.bindings.push()
+	defer pop()
+	if s.init != nil {
+		if err := s.init.Resolve(ctx); err != nil {
+			return err
+		}
+	}
+	for _, expr := range []Expression{s.cond, s.incr} {
+		if expr != nil {
+			if err := expr.Resolve(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	if s.body != nil {
+		return s.body.Resolve(ctx)
+	}
 	return nil
 }
 
 func (s *ExpressionStatement) Resolve(ctx *Context) error {
-	return nil
+	return s.expr.Resolve(ctx)
 }
 
 func (s *PrintStatement) Resolve(ctx *Context) error {
-	return nil
+	return s.expr.Resolve(ctx)
 }
 
 func (s *ReturnStatement) Resolve(ctx *Context) error {
-	return nil
+	return s.expr.Resolve(ctx)
 }
 
 func (e *UnaryExpression) Resolve(ctx *Context) error {
-	return nil
+	return e.right.Resolve(ctx)
 }
 
 func (e *BinaryExpression) Resolve(ctx *Context) error {
-	return nil
+	if err := e.left.Resolve(ctx); err != nil {
+		return err
+	}
+	return e.right.Resolve(ctx)
 }
 
 func (e *GroupingExpression) Resolve(ctx *Context) error {
-	return nil
+	return e.expr.Resolve(ctx)
 }
 
 func (e *CallExpression) Resolve(ctx *Context) error {
+	if err := e.callee.Resolve(ctx); err != nil {
+		return err
+	}
+	for _, arg := range e.args {
+		if err := arg.Resolve(ctx); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+func (e *PropertyExpression) Resolve(ctx *Context) error {
+	return e.object.Resolve(ctx)
+}
+
 func (e *StringExpression) Resolve(ctx *Context) error {
 	return nil
 }
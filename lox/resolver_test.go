@@ -1,4 +1,52 @@
 package lox
 
-type Resolver struct {
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestResolverBindings(t *testing.T) {
+	for _, test := range []struct {
+		text string
+		// declarations as "kind name@line:col [refs...]"
+		want []string
+	}{
+		{
+			text: "var a = 1; print a; a = 2;",
+			want: []string{"variable a@1:5 [(1,18) (1,21)]"},
+		},
+		{
+			text: "var a = 1; { var a = a; print a; } print a;",
+			want: []string{"variable a@1:5 [(1,22) (1,42)]", "variable a@1:18 [(1,31)]"},
+		},
+		{
+			text: "fun f(x) { return g(x); } fun g(y) { return y; }",
+			want: []string{"function f@1:1 []", "parameter x@1:7 [(1,21)]", "function g@1:27 [(1,19)]", "parameter y@1:33 [(1,45)]"},
+		},
+		{
+			text: "for (var i = 0; i < 1; i = i + 1) print i; print clock();",
+			want: []string{"variable i@1:10 [(1,17) (1,28) (1,24) (1,41)]"},
+		},
+	} {
+		ctx := NewContext(&PrintSpy{})
+		tokens, err := Scan(ctx, strings.NewReader(test.text))
+		if err != nil {
+			t.Fatalf("Unexpected error scanning %q: %s", test.text, err)
+		}
+		program, err := Parse(ctx, tokens)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %q: %s", test.text, err)
+		}
+		if err := Resolve(ctx, program); err != nil {
+			t.Fatalf("Unexpected error resolving %q: %s", test.text, err)
+		}
+		var got []string
+		for _, b := range ctx.Bindings().Declarations() {
+			got = append(got, fmt.Sprintf("%s %s@%d:%d %v", b.Kind, b.Name, b.Position.Line, b.Position.Column, b.References))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("Expected %q to resolve to %v, but got %v", test.text, test.want, got)
+		}
+	}
 }
@@ -0,0 +1,41 @@
+package lox
+
+import "math"
+
+// Approximate size in bytes of a newly created Env
+const envSize = 128
+
+// Approximate size in bytes of a value held in a list
+const valueSize = 16
+
+// Tracks the approximate number of bytes allocated by values and
+// environments over the lifetime of a context
+type Accountant struct {
+	used  int
+	limit int
+}
+
+// Returns the approximate number of bytes allocated so far
+func (a *Accountant) Used() int {
+	return a.used
+}
+
+// Returns the configured cap in bytes, or zero if unlimited
+func (a *Accountant) Limit() int {
+	return a.limit
+}
+
+// Records an allocation of n bytes, failing with a ResourceLimitError
+// once the configured cap is exceeded. A negative n, as results from an
+// overflowing size computation, is taken to be as large as possible.
+func (a *Accountant) Allocate(n int) error {
+	if n < 0 || a.used > math.MaxInt-n {
+		a.used = math.MaxInt
+	} else {
+		a.used += n
+	}
+	if a.limit > 0 && a.used > a.limit {
+		return NewResourceLimitError("memory", a.limit, a.used)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package lox
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAccountant(t *testing.T) {
+	tests := []struct {
+		limit  int
+		allocs []int
+		used   int
+		err    error
+	}{
+		{limit: 0, allocs: []int{10, 20}, used: 30},
+		{limit: 100, allocs: []int{60, 40}, used: 100},
+		{limit: 100, allocs: []int{60, 41}, used: 101, err: NewResourceLimitError("memory", 100, 101)},
+		{limit: 100, allocs: []int{10, -5}, used: math.MaxInt, err: NewResourceLimitError("memory", 100, math.MaxInt)},
+		{limit: 100, allocs: []int{10, math.MaxInt}, used: math.MaxInt, err: NewResourceLimitError("memory", 100, math.MaxInt)},
+		{limit: 0, allocs: []int{math.MaxInt, math.MaxInt}, used: math.MaxInt},
+	}
+	for _, test := range tests {
+		a := Accountant{limit: test.limit}
+		var err error
+		for _, n := range test.allocs {
+			err = a.Allocate(n)
+		}
+		if err != test.err {
+			t.Errorf("Expected allocating %v with limit %d to produce error %v, but got %v", test.allocs, test.limit, test.err, err)
+		}
+		if a.Used() != test.used {
+			t.Errorf("Expected allocating %v to use %d, but got %d", test.allocs, test.used, a.Used())
+		}
+	}
+}
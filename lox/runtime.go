@@ -3,29 +3,59 @@ package lox
 import (
 	"fmt"
 	"io"
+	"math/rand"
+	"os"
+	"sort"
 	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
 type Runtime struct {
-	writer io.Writer
-	funcs  map[string]Function
+	writer    io.Writer
+	errWriter io.Writer // destination of diagnostic output, such as debug()
+	funcs     map[string]Function
+	modules   map[string]*Module
+	rand      *rand.Rand
+	clock     Clock
+	epoch     time.Time // time at which elapsed() reads zero, and clock() its base
 }
 
 func NewRuntime(w io.Writer) *Runtime {
 	r := &Runtime{
-		writer: w,
-		funcs:  make(map[string]Function, 1),
+		writer:    w,
+		errWriter: os.Stderr,
+		funcs:     make(map[string]Function, 1),
+		modules:   make(map[string]*Module),
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
-	r.defun("clock", clock)
-	r.defun("sleep", sleep)
-	r.defun("debug", debug)
+	r.SetClock(systemClock{})
+	r.RegisterNative("clock", 0, nil, TypeNumeric, clock)
+	r.RegisterNative("elapsed", 0, nil, TypeNumeric, elapsed)
+	r.RegisterNative("sleep", 1, []Type{TypeNumeric}, TypeNil, sleep)
+	r.RegisterNative("debug", 0, nil, TypeNil, debug)
+	for _, natives := range [][]*BuiltinFunction{stringNatives, fileNatives, timeNatives, processNatives, listNatives} {
+		for _, fn := range natives {
+			r.funcs[fn.name] = fn
+		}
+	}
+	r.RegisterModule(newMathModule())
+	r.RegisterModule(newJSONModule())
+	r.RegisterModule(newRegexpModule())
 	return r
 }
 
-func (r *Runtime) defun(name string, fn func(*Context, ...Value) (Value, error)) {
-	r.funcs[name] = &BuiltinFunction{name: name, exec: fn}
+// Registers a global native function implemented by fn. It accepts arity
+// arguments (or any number if Variadic) whose types are given by params.
+// Parameters beyond arity are optional. Calls are checked against this
+// signature by the type checker.
+func (r *Runtime) RegisterNative(name string, arity int, params []Type, ret Type, fn NativeFunc) {
+	r.funcs[name] = NewBuiltinFunction(name, arity, params, ret, fn)
+}
+
+// Registers a module whose members are accessible as name.member
+func (r *Runtime) RegisterModule(m *Module) {
+	r.modules[m.name] = m
 }
 
 func (r *Runtime) Function(name string) Function {
@@ -35,33 +65,78 @@ func (r *Runtime) Function(name string) Function {
 	return nil
 }
 
+func (r *Runtime) Module(name string) *Module {
+	if m, ok := r.modules[name]; ok {
+		return m
+	}
+	return nil
+}
+
+// Returns the names of the global natives in sorted order
+func (r *Runtime) Functions() []string {
+	names := make([]string, 0, len(r.funcs))
+	for name := range r.funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Returns the names of the modules in sorted order
+func (r *Runtime) Modules() []string {
+	names := make([]string, 0, len(r.modules))
+	for name := range r.modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Seeds the generator used by math.random and math.randomInt
+func (r *Runtime) Seed(seed int64) {
+	r.rand.Seed(seed)
+}
+
+// Sets the source of time used by clock(), elapsed() and now()
+func (r *Runtime) SetClock(c Clock) {
+	r.clock = c
+	r.epoch = c.Now()
+}
+
 func (r *Runtime) Print(s string) error {
 	_, err := fmt.Fprintln(r.writer, s)
 	return err
 }
 
+// Returns the fractional number of seconds since the Unix epoch, advanced
+// by a monotonic clock from the time at which the runtime was created so
+// that changes to the system time do not affect measurements
 func clock(ctx *Context, args ...Value) (Value, error) {
-	if len(args) != 0 {
-		return nil, fmt.Errorf("clock expects no arguments, but got %d", len(args))
-	}
-	return ValueNumeric(time.Now().Unix()), nil
+	base := float64(ctx.runtime.epoch.UnixNano()) / float64(time.Second)
+	return ValueNumeric(base + ctx.runtime.clock.Now().Sub(ctx.runtime.epoch).Seconds()), nil
+}
+
+// Returns the fractional number of seconds elapsed since the runtime was
+// created, measured by a monotonic clock
+func elapsed(ctx *Context, args ...Value) (Value, error) {
+	return ValueNumeric(ctx.runtime.clock.Now().Sub(ctx.runtime.epoch).Seconds()), nil
 }
 
 func sleep(ctx *Context, args ...Value) (Value, error) {
-	if len(args) != 1 {
-		return nil, fmt.Errorf("sleep expects one argument, but got %d", len(args))
-	}
-	n, ok := args[0].(ValueNumeric)
-	if !ok {
-		return nil, fmt.Errorf("sleep expects one numeric argument, but got %s", args[0].Type())
-	}
+	n := args[0].(ValueNumeric)
 	secs := time.Duration(n) * time.Second
 	log.Debug().Msgf("(runtime) sleeping for %v", secs)
-	time.Sleep(secs)
+	timer := time.NewTimer(secs)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.done:
+		return nil, ErrCancelled
+	}
 	return Nil, nil
 }
 
 func debug(ctx *Context, _ ...Value) (Value, error) {
-	fmt.Fprintf(ctx.runtime.writer, "=== DEBUG ===\n%s\n=============\n", ctx.debug())
+	fmt.Fprintf(ctx.runtime.errWriter, "=== DEBUG ===\n%s\n=============\n", ctx.debug())
 	return Nil, nil
 }
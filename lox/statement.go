@@ -207,11 +207,12 @@ func (s *DeclarationStatement) Equals(other Statement) bool {
 }
 
 type FunctionDefinitionStatement struct {
-	name   string
-	params []string
-	body   []Statement
-	rtype  Type
-	pos    Position
+	name     string
+	params   []string
+	paramPos []Position // positions of the parameter names
+	body     []Statement
+	rtype    Type
+	pos      Position
 }
 
 func (s *FunctionDefinitionStatement) Position() Position {
@@ -0,0 +1,253 @@
+package lox
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var stringNatives = []*BuiltinFunction{
+	NewBuiltinFunction("len", 1, []Type{TypeString.Union(TypeList).Union(TypeMap)}, TypeNumeric, strLen),
+	NewBuiltinFunction("substr", 2, []Type{TypeString, TypeNumeric, TypeNumeric}, TypeString, strSubstr),
+	NewBuiltinFunction("indexOf", 2, []Type{TypeString, TypeString}, TypeNumeric, strIndexOf),
+	NewBuiltinFunction("split", 2, []Type{TypeString, TypeString}, TypeList, strSplit),
+	NewBuiltinFunction("join", 2, []Type{TypeList, TypeString}, TypeString, strJoin),
+	NewBuiltinFunction("upper", 1, []Type{TypeString}, TypeString, strUpper),
+	NewBuiltinFunction("lower", 1, []Type{TypeString}, TypeString, strLower),
+	NewBuiltinFunction("trim", 1, []Type{TypeString}, TypeString, strTrim),
+	NewBuiltinFunction("replace", 3, []Type{TypeString, TypeString, TypeString}, TypeString, strReplace),
+	NewBuiltinFunction("startsWith", 2, []Type{TypeString, TypeString}, TypeBoolean, strStartsWith),
+	NewBuiltinFunction("endsWith", 2, []Type{TypeString, TypeString}, TypeBoolean, strEndsWith),
+	NewBuiltinFunction("repeat", 2, []Type{TypeString, TypeNumeric}, TypeString, strRepeat),
+	NewBuiltinFunction("chars", 1, []Type{TypeString}, TypeList, strChars),
+	NewBuiltinFunction("ord", 1, []Type{TypeString}, TypeNumeric, strOrd),
+	NewBuiltinFunction("chr", 1, []Type{TypeNumeric}, TypeString, strChr),
+	NewBuiltinFunction("str", 1, []Type{TypeAny}, TypeString, strStr),
+	NewBuiltinFunction("num", 1, []Type{TypeString}, TypeNumeric, strNum),
+}
+
+// Names of the string natives which may be called as methods on a string,
+// i.e. s.upper() is equivalent to upper(s)
+var stringMethods = map[string]*BuiltinFunction{}
+
+func init() {
+	for _, fn := range stringNatives {
+		if len(fn.params) > 0 && fn.params[0] == TypeString {
+			stringMethods[fn.name] = fn
+		}
+	}
+}
+
+// Returns the string method name bound to the receiver, or nil if there is none
+func stringMethod(recv ValueString, name string) *BuiltinFunction {
+	fn, ok := stringMethods[name]
+	if !ok {
+		return nil
+	}
+	return bindReceiver(fn, recv)
+}
+
+// Returns a function which calls fn with the receiver as its first argument
+func bindReceiver(fn *BuiltinFunction, recv Value) *BuiltinFunction {
+	arity := fn.arity
+	if arity != Variadic {
+		arity -= 1
+	}
+	return NewBuiltinFunction(fn.name, arity, fn.params[1:], fn.ret, func(ctx *Context, args ...Value) (Value, error) {
+		return fn.exec(ctx, append([]Value{recv}, args...)...)
+	})
+}
+
+func strLen(_ *Context, args ...Value) (Value, error) {
+	switch v := args[0].(type) {
+	case ValueString:
+		return ValueNumeric(utf8.RuneCountInString(string(v))), nil
+	case *ValueList:
+		return ValueNumeric(v.Len()), nil
+	case *ValueMap:
+		return ValueNumeric(v.Len()), nil
+	}
+	return nil, NewInvalidArgumentTypeError("len", 0, args[0].Type(), TypeString.Union(TypeList).Union(TypeMap))
+}
+
+// Returns the code points of s from start, up to length of them if given
+func strSubstr(_ *Context, args ...Value) (Value, error) {
+	runes := []rune(string(args[0].(ValueString)))
+	start, err := integer("substr", 1, args[1])
+	if err != nil {
+		return nil, err
+	}
+	if start < 0 || start > int64(len(runes)) {
+		return nil, NewIndexOutOfRangeError(int(start), len(runes))
+	}
+	end := int64(len(runes))
+	if len(args) > 2 {
+		length, err := integer("substr", 2, args[2])
+		if err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			return nil, NewIndexOutOfRangeError(int(length), len(runes))
+		}
+		// compared so that start+length cannot overflow
+		if length < end-start {
+			end = start + length
+		}
+	}
+	return ValueString(runes[start:end]), nil
+}
+
+// Returns the index of the first code point of sub in s, or -1
+func strIndexOf(_ *Context, args ...Value) (Value, error) {
+	s, sub := string(args[0].(ValueString)), string(args[1].(ValueString))
+	i := strings.Index(s, sub)
+	if i < 0 {
+		return ValueNumeric(-1), nil
+	}
+	return ValueNumeric(utf8.RuneCountInString(s[:i])), nil
+}
+
+func strSplit(ctx *Context, args ...Value) (Value, error) {
+	parts := strings.Split(string(args[0].(ValueString)), string(args[1].(ValueString)))
+	if err := ctx.memory.Allocate(len(parts) * valueSize); err != nil {
+		return nil, err
+	}
+	return stringList(parts), nil
+}
+
+func strJoin(ctx *Context, args ...Value) (Value, error) {
+	list, sep := args[0].(*ValueList), string(args[1].(ValueString))
+	parts := make([]string, list.Len())
+	size := len(sep) * max(len(parts)-1, 0)
+	for i, elem := range list.elems {
+		s, ok := elem.(ValueString)
+		if !ok {
+			return nil, NewInvalidArgumentTypeError("join", 0, elem.Type(), TypeString)
+		}
+		parts[i] = string(s)
+		size += len(s)
+	}
+	if err := ctx.memory.Allocate(size); err != nil {
+		return nil, err
+	}
+	return ValueString(strings.Join(parts, sep)), nil
+}
+
+func strUpper(ctx *Context, args ...Value) (Value, error) {
+	s := strings.ToUpper(string(args[0].(ValueString)))
+	if err := ctx.memory.Allocate(len(s)); err != nil {
+		return nil, err
+	}
+	return ValueString(s), nil
+}
+
+func strLower(ctx *Context, args ...Value) (Value, error) {
+	s := strings.ToLower(string(args[0].(ValueString)))
+	if err := ctx.memory.Allocate(len(s)); err != nil {
+		return nil, err
+	}
+	return ValueString(s), nil
+}
+
+func strTrim(_ *Context, args ...Value) (Value, error) {
+	return ValueString(strings.TrimSpace(string(args[0].(ValueString)))), nil
+}
+
+// Replaces all occurrences of old in s with new
+func strReplace(ctx *Context, args ...Value) (Value, error) {
+	s, old, new := string(args[0].(ValueString)), string(args[1].(ValueString)), string(args[2].(ValueString))
+	// the size of the result is charged before it is built
+	n, growth := strings.Count(s, old), len(new)-len(old)
+	if growth > 0 && n > (math.MaxInt-len(s))/growth {
+		return nil, NewResultTooLargeError("replace")
+	}
+	if err := ctx.memory.Allocate(len(s) + n*growth); err != nil {
+		return nil, err
+	}
+	return ValueString(strings.ReplaceAll(s, old, new)), nil
+}
+
+func strStartsWith(_ *Context, args ...Value) (Value, error) {
+	return ValueBoolean(strings.HasPrefix(string(args[0].(ValueString)), string(args[1].(ValueString)))), nil
+}
+
+func strEndsWith(_ *Context, args ...Value) (Value, error) {
+	return ValueBoolean(strings.HasSuffix(string(args[0].(ValueString)), string(args[1].(ValueString)))), nil
+}
+
+func strRepeat(ctx *Context, args ...Value) (Value, error) {
+	s := string(args[0].(ValueString))
+	n, err := integer("repeat", 1, args[1])
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, NewIndexOutOfRangeError(int(n), 0)
+	}
+	if len(s) > 0 && n > int64(math.MaxInt/len(s)) {
+		return nil, NewResultTooLargeError("repeat")
+	}
+	if err := ctx.memory.Allocate(len(s) * int(n)); err != nil {
+		return nil, err
+	}
+	return ValueString(strings.Repeat(s, int(n))), nil
+}
+
+// Returns a list of the code points of s as strings
+func strChars(ctx *Context, args ...Value) (Value, error) {
+	runes := []rune(string(args[0].(ValueString)))
+	if err := ctx.memory.Allocate(len(runes) * valueSize); err != nil {
+		return nil, err
+	}
+	list := NewValueList(make([]Value, len(runes))...)
+	for i, r := range runes {
+		list.elems[i] = ValueString(r)
+	}
+	return list, nil
+}
+
+// Returns the code point of a single character string
+func strOrd(_ *Context, args ...Value) (Value, error) {
+	runes := []rune(string(args[0].(ValueString)))
+	if len(runes) != 1 {
+		return nil, NewNotACharacterError(string(runes))
+	}
+	return ValueNumeric(runes[0]), nil
+}
+
+// Returns the single character string for a code point
+func strChr(_ *Context, args ...Value) (Value, error) {
+	n, err := integer("chr", 0, args[0])
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > utf8.MaxRune || !utf8.ValidRune(rune(n)) {
+		return nil, NewIndexOutOfRangeError(int(n), utf8.MaxRune+1)
+	}
+	return ValueString(rune(n)), nil
+}
+
+// Returns the value as it would be printed
+func strStr(ctx *Context, args ...Value) (Value, error) {
+	if s, ok := args[0].(ValueString); ok {
+		return s, nil
+	}
+	str, err := args[0].Print(ctx.printer)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.memory.Allocate(len(str)); err != nil {
+		return nil, err
+	}
+	return ValueString(str), nil
+}
+
+func strNum(_ *Context, args ...Value) (Value, error) {
+	s := string(args[0].(ValueString))
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return nil, NewInvalidNumberError(s)
+	}
+	return ValueNumeric(n), nil
+}
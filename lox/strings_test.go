@@ -0,0 +1,73 @@
+package lox
+
+import (
+	"testing"
+)
+
+func TestStringNatives(t *testing.T) {
+	interp, err := NewInterpreter(WithStdout(&PrintSpy{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	abc := NewValueList(ValueString("a"), ValueString("b"), ValueString("c"))
+	tests := []struct {
+		text string
+		val  Value
+		err  error
+	}{
+		{text: "len(\"héllo\");", val: ValueNumeric(5)},
+		{text: "len(split(\"a,b\", \",\"));", val: ValueNumeric(2)},
+		{text: "substr(\"héllo\", 1);", val: ValueString("éllo")},
+		{text: "substr(\"héllo\", 1, 2);", val: ValueString("él")},
+		{text: "substr(\"héllo\", 3, 10);", val: ValueString("lo")},
+		{text: "substr(\"héllo\", 5);", val: ValueString("")},
+		{text: "substr(\"héllo\", 1, 9000000000000000000);", val: ValueString("éllo")},
+		{text: "indexOf(\"héllo\", \"l\");", val: ValueNumeric(2)},
+		{text: "indexOf(\"héllo\", \"x\");", val: ValueNumeric(-1)},
+		{text: "split(\"a,b,c\", \",\");", val: abc},
+		{text: "split(\"abc\", \"\");", val: abc},
+		{text: "join(split(\"a,b,c\", \",\"), \"-\");", val: ValueString("a-b-c")},
+		{text: "upper(\"abc\");", val: ValueString("ABC")},
+		{text: "lower(\"ABC\");", val: ValueString("abc")},
+		{text: "trim(\"  abc \");", val: ValueString("abc")},
+		{text: "replace(\"a.b.c\", \".\", \"/\");", val: ValueString("a/b/c")},
+		{text: "startsWith(\"abc\", \"ab\");", val: True},
+		{text: "endsWith(\"abc\", \"ab\");", val: False},
+		{text: "repeat(\"ab\", 3);", val: ValueString("ababab")},
+		{text: "repeat(\"\", 5000000000000000000);", val: ValueString("")},
+		{text: "chars(\"abc\");", val: abc},
+		{text: "ord(\"é\");", val: ValueNumeric(233)},
+		{text: "chr(233);", val: ValueString("é")},
+		{text: "str(1.5) + str(true) + str(nil) + str(\"s\");", val: ValueString("1.5truenils")},
+		{text: "num(\" 1.5 \") + 1;", val: ValueNumeric(2.5)},
+		{text: "\"abc\".upper();", val: ValueString("ABC")},
+		{text: "\"a-b\".replace(\"-\", \"+\").upper();", val: ValueString("A+B")},
+		{text: "var s = \"héllo\"; s.substr(1, 1);", val: ValueString("é")},
+		{text: "var f = \"abc\".startsWith; f(\"a\");", val: True},
+		{text: "upper(1);", err: NewTypeError(NewInvalidArgumentTypeError("upper", 0, TypeNumeric, TypeString), Position{1, 7})},
+		{text: "\"abc\".upper(1);", err: NewTypeError(NewArityMismatchError(0, 1), Position{1, 12})},
+		{text: "\"abc\".repeat(\"x\");", err: NewTypeError(NewInvalidArgumentTypeError("repeat", 0, TypeString, TypeNumeric), Position{1, 14})},
+		{text: "\"abc\".length();", err: NewTypeError(NewUndefinedPropertyError("length", TypeString), Position{1, 6})},
+		{text: "substr(\"abc\", 4);", err: NewRuntimeError(NewIndexOutOfRangeError(4, 3), Position{1, 7})},
+		{text: "substr(\"abc\", 0.5);", err: NewRuntimeError(NewNonIntegerArgumentError("substr", 1, ValueNumeric(0.5)), Position{1, 7})},
+		{text: "repeat(\"ab\", 5000000000000000000);", err: NewRuntimeError(NewResultTooLargeError("repeat"), Position{1, 7})},
+		{text: "num(\"abc\");", err: NewRuntimeError(NewInvalidNumberError("abc"), Position{1, 4})},
+		{text: "ord(\"ab\");", err: NewRuntimeError(NewNotACharacterError("ab"), Position{1, 4})},
+		{text: "join(chars(\"ab\") , 1);", err: NewTypeError(NewInvalidArgumentTypeError("join", 1, TypeNumeric, TypeString), Position{1, 20})},
+	}
+	for _, test := range tests {
+		val, err := interp.Eval(test.text)
+		if test.err != nil {
+			if err != test.err {
+				t.Errorf("Expected %q to produce error %q, but got %v", test.text, test.err, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("Unexpected error in %q: %s", test.text, err)
+			continue
+		}
+		if !test.val.Equals(val) {
+			t.Errorf("Expected %q to yield %s, but got %s", test.text, test.val, val)
+		}
+	}
+}
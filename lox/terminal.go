@@ -44,6 +44,15 @@ func (t *Terminal) ReadLine() (string, error) {
 	return t.terminal.ReadLine()
 }
 
+// Sets the prompt shown by subsequent reads
+func (t *Terminal) SetPrompt(prompt string) error {
+	if t.terminal == nil {
+		return TerminalClosedError{"SetPrompt"}
+	}
+	t.terminal.SetPrompt(prompt)
+	return nil
+}
+
 func (t *Terminal) Write(buf []byte) (int, error) {
 	if t.terminal == nil {
 		return -1, TerminalClosedError{"Write"}
@@ -56,6 +65,7 @@ func (t *Terminal) WriteError(err error) error {
 		return TerminalClosedError{"WriteError"}
 	}
 	data := t.terminal.Escape.Yellow
+	data = append(data, []byte(Traceback(err))...)
 	data = append(data, []byte(err.Error())...)
 	data = append(data, t.terminal.Escape.Reset...)
 	data = append(data, '\n')
@@ -0,0 +1,128 @@
+package lox
+
+import (
+	"time"
+)
+
+// A source of the current time, which may be replaced to make scripts
+// deterministic
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+var timeNatives = []*BuiltinFunction{
+	NewBuiltinFunction("now", 0, nil, TypeTime, now),
+	NewBuiltinFunction("formatTime", 1, []Type{TypeTime, TypeString}, TypeString, formatTime),
+	NewBuiltinFunction("parseTime", 1, []Type{TypeString, TypeString}, TypeTime, parseTime),
+	NewBuiltinFunction("addTime", 2, []Type{TypeTime, TypeNumeric}, TypeTime, addTime),
+	NewBuiltinFunction("diffTime", 2, []Type{TypeTime, TypeTime}, TypeNumeric, diffTime),
+	NewBuiltinFunction("duration", 1, []Type{TypeString}, TypeNumeric, duration),
+}
+
+// Properties of time values, all of which are numeric
+var timeProperties = map[string]func(time.Time) float64{
+	"year":    func(t time.Time) float64 { return float64(t.Year()) },
+	"month":   func(t time.Time) float64 { return float64(t.Month()) },
+	"day":     func(t time.Time) float64 { return float64(t.Day()) },
+	"weekday": func(t time.Time) float64 { return float64(t.Weekday()) },
+	"hour":    func(t time.Time) float64 { return float64(t.Hour()) },
+	"minute":  func(t time.Time) float64 { return float64(t.Minute()) },
+	"second":  func(t time.Time) float64 { return float64(t.Second()) },
+	"unix":    func(t time.Time) float64 { return float64(t.UnixNano()) / float64(time.Second) },
+}
+
+// Layout used to format and parse times unless another is given
+const defaultTimeLayout = time.RFC3339Nano
+
+// A point in time
+type ValueTime struct {
+	t time.Time
+}
+
+func NewValueTime(t time.Time) ValueTime {
+	return ValueTime{t: t}
+}
+
+func (v ValueTime) Time() time.Time {
+	return v.t
+}
+
+func (v ValueTime) String() string {
+	str, err := v.Print(&defaultPrinter)
+	if err != nil {
+		panic(err)
+	}
+	return str
+}
+
+func (ValueTime) Type() Type {
+	return TypeTime
+}
+
+func (v ValueTime) Truthy() bool {
+	return true
+}
+
+func (v ValueTime) Equals(other Value) bool {
+	t, ok := other.(ValueTime)
+	return ok && v.t.Equal(t.t)
+}
+
+func (v ValueTime) MarshalJSON() ([]byte, error) {
+	return marshalJSON(v.t.Format(defaultTimeLayout))
+}
+
+// Returns the current time
+func now(ctx *Context, _ ...Value) (Value, error) {
+	return NewValueTime(ctx.runtime.clock.Now()), nil
+}
+
+// Formats the time using a go layout, RFC 3339 by default
+func formatTime(_ *Context, args ...Value) (Value, error) {
+	layout := defaultTimeLayout
+	if len(args) > 1 {
+		layout = string(args[1].(ValueString))
+	}
+	return ValueString(args[0].(ValueTime).t.Format(layout)), nil
+}
+
+// Parses the time using a go layout, RFC 3339 by default
+func parseTime(_ *Context, args ...Value) (Value, error) {
+	str, layout := string(args[0].(ValueString)), defaultTimeLayout
+	if len(args) > 1 {
+		layout = string(args[1].(ValueString))
+	}
+	t, err := time.Parse(layout, str)
+	if err != nil {
+		return nil, NewInvalidTimeError(str, layout)
+	}
+	return NewValueTime(t), nil
+}
+
+// Returns the time offset by a number of seconds
+func addTime(_ *Context, args ...Value) (Value, error) {
+	t, secs := args[0].(ValueTime).t, float64(args[1].(ValueNumeric))
+	return NewValueTime(t.Add(time.Duration(secs * float64(time.Second)))), nil
+}
+
+// Returns the number of seconds elapsed between the second time and the first
+func diffTime(_ *Context, args ...Value) (Value, error) {
+	a, b := args[0].(ValueTime).t, args[1].(ValueTime).t
+	return ValueNumeric(a.Sub(b).Seconds()), nil
+}
+
+// Returns the number of seconds in a duration such as "1h30m"
+func duration(_ *Context, args ...Value) (Value, error) {
+	str := string(args[0].(ValueString))
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return nil, NewInvalidTimeError(str, "duration")
+	}
+	return ValueNumeric(d.Seconds()), nil
+}
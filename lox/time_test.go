@@ -0,0 +1,85 @@
+package lox
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestTimeNatives(t *testing.T) {
+	start := time.Date(2024, time.March, 9, 13, 45, 30, 0, time.UTC)
+	clk := &fakeClock{now: start}
+	interp, err := NewInterpreter(WithStdout(&PrintSpy{}), WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val, err := interp.Eval("elapsed();"); err != nil || !val.Equals(ValueNumeric(0)) {
+		t.Errorf("Expected elapsed() to start at 0, but got %v (%v)", val, err)
+	}
+	clk.now = start.Add(1500 * time.Millisecond)
+	if val, err := interp.Eval("elapsed();"); err != nil || !val.Equals(ValueNumeric(1.5)) {
+		t.Errorf("Expected elapsed() to yield 1.5, but got %v (%v)", val, err)
+	}
+	if val, err := interp.Eval("clock();"); err != nil || !val.Equals(ValueNumeric(float64(start.Unix())+1.5)) {
+		t.Errorf("Expected clock() to yield Unix time, but got %v (%v)", val, err)
+	}
+
+	now := clk.now
+	tests := []struct {
+		text string
+		val  Value
+		err  error
+	}{
+		{text: "now();", val: NewValueTime(now)},
+		{text: "formatTime(now());", val: ValueString("2024-03-09T13:45:31.5Z")},
+		{text: "formatTime(now(), \"2006-01-02\");", val: ValueString("2024-03-09")},
+		{text: "var t = now(); t.year * 10000 + t.month * 100 + t.day;", val: ValueNumeric(20240309)},
+		{text: "now().hour + now().minute + now().second;", val: ValueNumeric(13 + 45 + 31)},
+		{text: "now().weekday;", val: ValueNumeric(time.Saturday)},
+		{text: "now().unix;", val: ValueNumeric(float64(now.Unix()) + 0.5)},
+		{text: "parseTime(\"2024-03-09T13:45:31.5Z\") == now();", val: True},
+		{text: "parseTime(\"09/03/2024\", \"02/01/2006\");", val: NewValueTime(time.Date(2024, time.March, 9, 0, 0, 0, 0, time.UTC))},
+		{text: "formatTime(addTime(now(), duration(\"1h30m\")));", val: ValueString("2024-03-09T15:15:31.5Z")},
+		{text: "diffTime(addTime(now(), -90), now());", val: ValueNumeric(-90)},
+		{text: "duration(\"250ms\");", val: ValueNumeric(0.25)},
+		{text: "now().century;", err: NewTypeError(NewUndefinedPropertyError("century", TypeTime), Position{1, 6})},
+		{text: "diffTime(now(), 1);", err: NewTypeError(NewInvalidArgumentTypeError("diffTime", 1, TypeNumeric, TypeTime), Position{1, 17})},
+		{text: "parseTime(\"yesterday\");", err: NewRuntimeError(NewInvalidTimeError("yesterday", time.RFC3339Nano), Position{1, 10})},
+		{text: "duration(\"soon\");", err: NewRuntimeError(NewInvalidTimeError("soon", "duration"), Position{1, 9})},
+	}
+	for _, test := range tests {
+		val, err := interp.Eval(test.text)
+		if test.err != nil {
+			if err != test.err {
+				t.Errorf("Expected %q to produce error %q, but got %v", test.text, test.err, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("Unexpected error in %q: %s", test.text, err)
+			continue
+		}
+		if !test.val.Equals(val) {
+			t.Errorf("Expected %q to yield %s, but got %s", test.text, test.val, val)
+		}
+	}
+}
+
+func TestTimeConversion(t *testing.T) {
+	now := time.Date(2024, time.March, 9, 0, 0, 0, 0, time.UTC)
+	val, err := FromGo(now)
+	if err != nil || !val.Equals(NewValueTime(now)) {
+		t.Fatalf("Expected %s to convert to a time, but got %v (%v)", now, val, err)
+	}
+	out, err := ToGo(val, reflect.TypeOf(time.Time{}))
+	if err != nil || !out.(time.Time).Equal(now) {
+		t.Errorf("Expected %s to convert back to %s, but got %v (%v)", val, now, out, err)
+	}
+}
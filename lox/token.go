@@ -110,6 +110,11 @@ func (p Position) String() string {
 	return fmt.Sprintf("(%d,%d)", p.Line, p.Column)
 }
 
+// Whether the position precedes the other in the source
+func (p Position) Before(other Position) bool {
+	return p.Line < other.Line || p.Line == other.Line && p.Column < other.Column
+}
+
 var ErrPosition = Position{-1, -1}
 
 func (p Position) Invalid() bool {
@@ -0,0 +1,147 @@
+package lox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type TraceKind string
+
+const (
+	TraceStatement TraceKind = "statement" // a statement is about to execute
+	TraceCall      TraceKind = "call"      // a user function was entered
+	TraceReturn    TraceKind = "return"    // a user function returned
+	TraceDeclare   TraceKind = "declare"   // a variable or function was declared
+	TraceAssign    TraceKind = "assign"    // a variable was assigned
+)
+
+// An event in the execution of a program
+type TraceEvent struct {
+	Kind      TraceKind
+	Position  Position // of the statement, call site, declaration or assignment
+	Depth     int      // number of active calls
+	Name      string   // of the function or variable
+	Statement string   // the statement, or the header of a compound statement
+	Values    []Value  // arguments of a call, or the value returned or assigned
+	Err       error    // error with which a call returned
+}
+
+// Receives the events of an executing program. Tracing costs nothing unless
+// a Tracer is set with WithTracer or Context.SetTracer.
+type Tracer interface {
+	Trace(ev TraceEvent)
+}
+
+// Sets the tracer receiving execution events
+func (ctx *Context) SetTracer(t Tracer) {
+	ctx.tracer = t
+}
+
+func (ctx *Context) trace(ev TraceEvent) {
+	ev.Depth = len(ctx.calls)
+	ctx.tracer.Trace(ev)
+}
+
+func (ctx *Context) traceStatement(stmt Statement) {
+	if counted(stmt) {
+		header := (&Formatter{}).header(stmt)
+		ctx.trace(TraceEvent{Kind: TraceStatement, Position: stmt.Position(), Statement: header})
+	}
+}
+
+// Writes events as indented lines of text
+type TextTracer struct {
+	w   io.Writer
+	err error
+}
+
+func NewTextTracer(w io.Writer) *TextTracer {
+	return &TextTracer{w: w}
+}
+
+func (t *TextTracer) Trace(ev TraceEvent) {
+	if t.err != nil {
+		return
+	}
+	var detail string
+	switch ev.Kind {
+	case TraceStatement:
+		detail = ev.Statement
+	case TraceCall:
+		detail = ev.Name + "(" + strings.Join(traceValues(ev.Values), ", ") + ")"
+	case TraceReturn:
+		if ev.Err != nil {
+			detail = fmt.Sprintf("%s: %s", ev.Name, ev.Err)
+		} else {
+			detail = ev.Name + " -> " + strings.Join(traceValues(ev.Values), ", ")
+		}
+	case TraceDeclare, TraceAssign:
+		detail = ev.Name + " = " + strings.Join(traceValues(ev.Values), ", ")
+	}
+	_, t.err = fmt.Fprintf(t.w, "%d:%d\t%s%s %s\n", ev.Position.Line, ev.Position.Column, strings.Repeat("  ", ev.Depth), ev.Kind, detail)
+}
+
+// Returns the first error writing an event, if any
+func (t *TextTracer) Err() error {
+	return t.err
+}
+
+// Writes each event as a line of JSON
+type JSONTracer struct {
+	enc *json.Encoder
+	err error
+}
+
+type jsonTraceEvent struct {
+	Kind      TraceKind `json:"kind"`
+	Line      int       `json:"line"`
+	Column    int       `json:"column"`
+	Depth     int       `json:"depth"`
+	Name      string    `json:"name,omitempty"`
+	Statement string    `json:"statement,omitempty"`
+	Values    []string  `json:"values,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+func NewJSONTracer(w io.Writer) *JSONTracer {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &JSONTracer{enc: enc}
+}
+
+func (t *JSONTracer) Trace(ev TraceEvent) {
+	if t.err != nil {
+		return
+	}
+	out := jsonTraceEvent{
+		Kind:      ev.Kind,
+		Line:      ev.Position.Line,
+		Column:    ev.Position.Column,
+		Depth:     ev.Depth,
+		Name:      ev.Name,
+		Statement: ev.Statement,
+		Values:    traceValues(ev.Values),
+	}
+	if ev.Err != nil {
+		out.Error = ev.Err.Error()
+	}
+	t.err = t.enc.Encode(out)
+}
+
+// Returns the first error writing an event, if any
+func (t *JSONTracer) Err() error {
+	return t.err
+}
+
+func traceValues(vals []Value) []string {
+	if len(vals) == 0 {
+		return nil
+	}
+	strs := make([]string, len(vals))
+	for i, val := range vals {
+		strs[i] = val.String()
+	}
+	return strs
+}
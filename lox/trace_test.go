@@ -0,0 +1,96 @@
+package lox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTextTracer(t *testing.T) {
+	for _, test := range []struct {
+		text     string
+		expected []string
+	}{
+		{
+			text: "var a = 1;\na = a + 1;",
+			expected: []string{
+				"1:5\tstatement var a = 1;",
+				"1:5\tdeclare a = 1",
+				"2:1\tstatement a = a + 1;",
+				"2:1\tassign a = 2",
+			},
+		},
+		{
+			text: "fun f(n) {\n\treturn n * 2;\n}\nprint f(3);",
+			expected: []string{
+				"1:1\tstatement fun f(n)",
+				"1:1\tdeclare f = Callable(f)",
+				"4:1\tstatement print f(3);",
+				"4:8\t  call f(3)",
+				"2:2\t  statement return n * 2;",
+				"4:8\t  return f -> 6",
+			},
+		},
+		{
+			text: "fun f() {\n\treturn 1 / nil;\n}\nf();",
+			expected: []string{
+				"1:1\tstatement fun f()",
+				"1:1\tdeclare f = Callable(f)",
+				"4:2\tstatement f();",
+				"4:2\t  call f()",
+				"2:2\t  statement return 1 / nil;",
+				"4:2\t  return f: Runtime Error on line 2: binary operator Divide can't be applied to types Type{TypeNumeric} and Type{TypeNil}",
+			},
+		},
+		{
+			text: "if (true) {\n\tvar b;\n}",
+			expected: []string{
+				"1:1\tstatement if (true)",
+				"2:6\tstatement var b;",
+				"2:6\tdeclare b = nil",
+			},
+		},
+	} {
+		var out strings.Builder
+		tracer := NewTextTracer(&out)
+		interp, err := NewInterpreter(WithStdout(&PrintSpy{}), WithTracer(tracer))
+		if err != nil {
+			t.Fatal(err)
+		}
+		interp.Eval(test.text)
+		if err := tracer.Err(); err != nil {
+			t.Fatal(err)
+		}
+		expected := strings.Join(test.expected, "\n") + "\n"
+		if out.String() != expected {
+			t.Errorf("Expected trace of %q to be %q, but got %q", test.text, expected, out.String())
+		}
+	}
+}
+
+func TestJSONTracer(t *testing.T) {
+	var out strings.Builder
+	tracer := NewJSONTracer(&out)
+	interp, err := NewInterpreter(WithStdout(&PrintSpy{}), WithTracer(tracer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := "fun f(s) {\n\treturn s + \"<\";\n}\nf(\"a\");"
+	if _, err := interp.Eval(text); err != nil {
+		t.Fatalf("Unexpected error in %q: %s", text, err)
+	}
+	if err := tracer.Err(); err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		`{"kind":"statement","line":1,"column":1,"depth":0,"statement":"fun f(s)"}`,
+		`{"kind":"declare","line":1,"column":1,"depth":0,"name":"f","values":["Callable(f)"]}`,
+		`{"kind":"statement","line":4,"column":2,"depth":0,"statement":"f(\"a\");"}`,
+		`{"kind":"call","line":4,"column":2,"depth":1,"name":"f","values":["\"a\""]}`,
+		`{"kind":"statement","line":2,"column":2,"depth":1,"statement":"return s + \"<\";"}`,
+		`{"kind":"return","line":4,"column":2,"depth":1,"name":"f","values":["\"a<\""]}`,
+		"",
+	}, "\n")
+	if out.String() != expected {
+		t.Errorf("Expected trace %q, but got %q", expected, out.String())
+	}
+}
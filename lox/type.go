@@ -1,6 +1,7 @@
 package lox
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -16,6 +17,11 @@ const (
 	typeUtf8Bit
 	typeStringBit
 	typeCallable
+	typeModuleBit
+	typeListBit
+	typeMapBit
+	typeTimeBit
+	typeRegexpBit
 )
 
 var TypeAny = Type{bits: ^uint(0)}
@@ -26,9 +32,14 @@ var TypeBoolean = Type{bits: uint(typeBooleanBit)}
 var TypeNumeric = Type{bits: uint(typeUint64Bit | typeInt64Bit | typeFloat64Bit)}
 var TypeString = Type{bits: uint(typeStringBit)}
 var TypeCallable = Type{bits: uint(typeCallable)}
+var TypeModule = Type{bits: uint(typeModuleBit)}
+var TypeList = Type{bits: uint(typeListBit)}
+var TypeMap = Type{bits: uint(typeMapBit)}
+var TypeTime = Type{bits: uint(typeTimeBit)}
+var TypeRegexp = Type{bits: uint(typeRegexpBit)}
 
-var allTypes = [...]Type{TypeNil, TypeBoolean, TypeNumeric, TypeString, TypeCallable}
-var typeStrings = [...]string{"TypeNil", "TypeBoolean", "TypeNumeric", "TypeString", "TypeCallable"}
+var allTypes = [...]Type{TypeNil, TypeBoolean, TypeNumeric, TypeString, TypeCallable, TypeModule, TypeList, TypeMap, TypeTime, TypeRegexp}
+var typeStrings = [...]string{"TypeNil", "TypeBoolean", "TypeNumeric", "TypeString", "TypeCallable", "TypeModule", "TypeList", "TypeMap", "TypeTime", "TypeRegexp"}
 
 type Type struct {
 	bits uint
@@ -94,3 +105,57 @@ func (t *Type) Clear(u Type) {
 func (t *Type) Zero() {
 	t.bits = 0
 }
+
+// Encodes the type as the list of its member type names
+func (t Type) MarshalJSON() ([]byte, error) {
+	if t == TypeAny {
+		return json.Marshal([]string{"Any"})
+	}
+	return json.Marshal(t.names())
+}
+
+// Returns the names of the member types
+func (t Type) names() []string {
+	names := []string{}
+	for i, typ := range allTypes {
+		if t.Contains(typ) {
+			names = append(names, strings.TrimPrefix(typeStrings[i], "Type"))
+		}
+	}
+	return names
+}
+
+// Returns the type as written in signatures, such as Numeric|String
+func (t Type) Name() string {
+	switch t {
+	case TypeAny:
+		return "Any"
+	case TypeNone:
+		return "None"
+	}
+	return strings.Join(t.names(), "|")
+}
+
+// Decodes the type from the list of its member type names
+func (t *Type) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	t.Zero()
+outer:
+	for _, name := range names {
+		if name == "Any" {
+			*t = TypeAny
+			continue
+		}
+		for i, str := range typeStrings {
+			if "Type"+name == str {
+				t.Set(allTypes[i])
+				continue outer
+			}
+		}
+		return NewUnknownASTNodeError("Type", name)
+	}
+	return nil
+}
@@ -4,6 +4,9 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// Types whose values may have properties
+var propertyTypes = TypeModule.Union(TypeMap).Union(TypeString).Union(TypeTime)
+
 func Typecheck(ctx *Context, elems []Statement) error {
 	restore := ctx.StartPhase(PhaseTypecheck)
 	defer restore()
@@ -113,7 +116,7 @@ func (s *FunctionDefinitionStatement) Typecheck(ctx *Context) error {
 	// 	}
 	// }
 	s.rtype = TypeAny
-	return nil
+	return debugSetType(ctx.Phase(), ctx.env, s.name, TypeCallable)
 }
 
 func (s *ReturnStatement) Typecheck(ctx *Context) error {
@@ -173,37 +176,81 @@ func (e *AssignmentExpression) Typecheck(ctx *Context) error {
 func (e *VariableExpression) Typecheck(ctx *Context) error {
 	typ, _ := ctx.env.ResolveType(e.name)
 	if typ == TypeNone {
-		return NewTypeError(NewUndefinedVariableError(e.name), e.Position())
+		if ctx.runtime.Function(e.name) != nil {
+			typ = TypeCallable
+		} else if ctx.runtime.Module(e.name) != nil {
+			typ = TypeModule
+		} else {
+			return NewTypeError(NewUndefinedVariableError(e.name), e.Position())
+		}
 	}
 	e.typ = typ
 	return nil
 }
 
+func (e *PropertyExpression) Typecheck(ctx *Context) error {
+	if err := e.object.Typecheck(ctx); err != nil {
+		return err
+	}
+	if m := staticModule(ctx, e.object); m != nil {
+		val := m.Member(e.name)
+		if val == nil {
+			return NewTypeError(NewUndefinedPropertyError(e.name, TypeModule), e.Position())
+		}
+		e.typ = val.Type()
+		return nil
+	}
+	typ := e.object.Type()
+	if !typ.Test(propertyTypes) {
+		return NewTypeError(NewTypeHasNoPropertiesError(typ), e.Position())
+	}
+	if typ == TypeString {
+		if _, ok := stringMethods[e.name]; !ok {
+			return NewTypeError(NewUndefinedPropertyError(e.name, TypeString), e.Position())
+		}
+		e.typ = TypeCallable
+		return nil
+	}
+	if typ == TypeTime {
+		if _, ok := timeProperties[e.name]; !ok {
+			return NewTypeError(NewUndefinedPropertyError(e.name, TypeTime), e.Position())
+		}
+		e.typ = TypeNumeric
+		return nil
+	}
+	e.typ = TypeAny
+	return nil
+}
+
 func (e *CallExpression) Typecheck(ctx *Context) error {
-	// TODO: Typecheck call expression
-	// if err := e.callee.Typecheck(ctx); err != nil {
-	// 	return err
-	// }
-	// typ := e.callee.Type()
-	// if !typ.Contains(TypeCallable) {
-	// 	return NewTypeError(NewTypeNotCallableError(typ), e.Position())
-	// }
-	// fn, _ := ctx.funcs.Lookup(typ.name)
-	// if fn == nil {
-	// 	return NewTypeError(NewUndefinedVariableError(typ.name), e.Position())
-	// }
-	// if arity, nargs := fn.Arity(), len(e.args); nargs != arity {
-	// 	return NewTypeError(NewArityMismatchError(arity, nargs), e.Position())
-	// }
-	// for i, arg := range e.args {
-	// 	if err := arg.Typecheck(ctx); err != nil {
-	// 		return err
-	// 	}
-	// 	if param := fn.Params[i]; !param.Type.Contains(arg.Type()) {
-	// 		return NewTypeError(NewInvalidArgumentTypeForParameter(arg.Type(), param), e.Position())
-	// 	}
-	// }
+	if err := e.callee.Typecheck(ctx); err != nil {
+		return err
+	}
+	if typ := e.callee.Type(); !typ.Test(TypeCallable) {
+		return NewTypeError(NewTypeNotCallableError(typ), e.Position())
+	}
+	for _, arg := range e.args {
+		if err := arg.Typecheck(ctx); err != nil {
+			return err
+		}
+	}
 	e.typ = TypeAny
+	fn := staticNative(ctx, e.callee)
+	if fn == nil {
+		// TODO: Typecheck calls to user functions
+		return nil
+	}
+	if err := fn.CheckArity(len(e.args)); err != nil {
+		return NewTypeError(err, e.Position())
+	}
+	for i, arg := range e.args {
+		if param, ok := fn.ParamType(i); ok && !arg.Type().Test(param) {
+			return NewTypeError(
+				NewInvalidArgumentTypeError(fn.name, i, arg.Type(), param), arg.Position(),
+			)
+		}
+	}
+	e.typ = fn.ret
 	return nil
 }
 
@@ -282,3 +329,37 @@ func typecheckBinary(e *BinaryExpression, left Type, right Type) (result Type, e
 	_ = s
 	return result, err
 }
+
+// Returns the module referenced by the expression if it can be determined
+// statically, i.e. the name is not shadowed by a variable
+func staticModule(ctx *Context, expr Expression) *Module {
+	if v, ok := expr.(*VariableExpression); ok {
+		if typ, _ := ctx.env.ResolveType(v.name); typ == TypeNone {
+			return ctx.runtime.Module(v.name)
+		}
+	}
+	return nil
+}
+
+// Returns the native function referenced by the expression if it can be
+// determined statically, i.e. the name is not shadowed by a variable
+func staticNative(ctx *Context, expr Expression) *BuiltinFunction {
+	switch e := expr.(type) {
+	case *VariableExpression:
+		if typ, _ := ctx.env.ResolveType(e.name); typ == TypeNone {
+			fn, _ := ctx.runtime.Function(e.name).(*BuiltinFunction)
+			return fn
+		}
+	case *PropertyExpression:
+		if m := staticModule(ctx, e.object); m != nil {
+			if call, ok := m.Member(e.name).(*ValueCallable); ok {
+				fn, _ := call.fn.(*BuiltinFunction)
+				return fn
+			}
+		} else if e.object.Type() == TypeString {
+			// the receiver is irrelevant to the signature
+			return stringMethod("", e.name)
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 )
 
 type Value interface {
@@ -238,3 +239,116 @@ func (v ValueCallable) Equals(other Value) bool {
 func (v ValueCallable) Call(ctx *Context, args ...Value) (Value, error) {
 	return v.fn.Execute(ctx, v.name, args...)
 }
+
+type ValueList struct {
+	elems []Value
+}
+
+func NewValueList(elems ...Value) *ValueList {
+	return &ValueList{elems: elems}
+}
+
+func (v *ValueList) String() string {
+	str, err := v.Print(&defaultPrinter)
+	if err != nil {
+		panic(err)
+	}
+	return str
+}
+
+func (v *ValueList) Type() Type {
+	return TypeList
+}
+
+func (v *ValueList) Truthy() bool {
+	return true
+}
+
+func (v *ValueList) Equals(other Value) bool {
+	list, ok := other.(*ValueList)
+	if !ok || len(v.elems) != len(list.elems) {
+		return false
+	}
+	for i, elem := range v.elems {
+		if !elem.Equals(list.elems[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *ValueList) Len() int {
+	return len(v.elems)
+}
+
+func (v *ValueList) At(i int) Value {
+	return v.elems[i]
+}
+
+func (v *ValueList) Append(vals ...Value) {
+	v.elems = append(v.elems, vals...)
+}
+
+func (v *ValueList) Elements() []Value {
+	return v.elems
+}
+
+type ValueMap struct {
+	entries map[string]Value
+}
+
+func NewValueMap() *ValueMap {
+	return &ValueMap{entries: make(map[string]Value)}
+}
+
+func (v *ValueMap) String() string {
+	str, err := v.Print(&defaultPrinter)
+	if err != nil {
+		panic(err)
+	}
+	return str
+}
+
+func (v *ValueMap) Type() Type {
+	return TypeMap
+}
+
+func (v *ValueMap) Truthy() bool {
+	return true
+}
+
+func (v *ValueMap) Equals(other Value) bool {
+	m, ok := other.(*ValueMap)
+	if !ok || len(v.entries) != len(m.entries) {
+		return false
+	}
+	for key, val := range v.entries {
+		if o, ok := m.entries[key]; !ok || !val.Equals(o) {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *ValueMap) Len() int {
+	return len(v.entries)
+}
+
+func (v *ValueMap) Get(key string) (Value, bool) {
+	val, ok := v.entries[key]
+	return val, ok
+}
+
+func (v *ValueMap) Set(key string, val Value) {
+	v.entries[key] = val
+}
+
+// Returns the keys of the map in sorted order
+func (v *ValueMap) Keys() []string {
+	keys := make([]string, 0, len(v.entries))
+	for key := range v.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,29 +1,43 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"strings"
 
 	"github.com/rs/zerolog/log"
 	"github.com/vdinovi/glox/lox"
 )
 
-const usagef = `Usage: %s [file]
+const usagef = `Usage: %s [file [args...]]
+       %[1]s run [-coverage] [-coverage-file file] file [args...]
+       %[1]s fmt [-check] [-w] [files...]
+       %[1]s debug [-break lines] file [args...]
        starts a repl if no file is provided.
+       args are available to the script through args().
+       run runs the file, writing an lcov report of its coverage if requested.
+       fmt formats the files, or stdin if none are provided.
+       debug runs the file under a debugger reading commands from stdin.
 `
 
 func main() {
-	err := setup()
+	opts, prof, err := setup()
 	if err == nil {
 		if flag.NArg() == 0 {
-			err = interactive()
+			err = interactive(opts)
+		} else if flag.Arg(0) == "fmt" {
+			err = format(flag.Args()[1:])
+		} else if flag.Arg(0) == "run" {
+			err = run(flag.Args()[1:], opts, prof)
+		} else if flag.Arg(0) == "debug" {
+			err = debug(flag.Args()[1:], opts)
 		} else {
-			err = file(flag.Arg(0))
+			err = file(flag.Arg(0), flag.Args()[1:], opts, prof)
 		}
 	}
 	if err != nil {
@@ -32,44 +46,77 @@ func main() {
 	lox.Exit(lox.ExitCodeOK)
 }
 
-func setup() error {
+func setup() ([]lox.Option, *profiling, error) {
 	logLevel := flag.String("log", "", "enable logging at specified level")
+	allowFiles := flag.String("allow-files", ".", "comma-separated directories scripts may access, or empty to deny access")
+	profile := flag.Bool("profile", false, "print call counts and timings of functions and statements to stderr after running a file")
+	profileFolded := flag.String("profile-folded", "", "write folded call stacks of a file's run to the named file, for flamegraph tools")
+	trace := flag.String("trace", "", "write a trace of execution to stderr as \"text\" or \"json\" lines")
 	flag.Usage = func() {
 		fmt.Fprintf(flag.CommandLine.Output(), usagef, os.Args[0])
 		flag.PrintDefaults()
 	}
 	flag.Parse()
 
-	if *logLevel == "" {
-		lox.DisableLogger()
-	} else {
+	lox.DisableLogger()
+	if *logLevel != "" {
 		lox.SetConsoleLogOutput(os.Stderr)
-		lox.SetLogLevel(*logLevel)
+		if err := lox.SetLogLevel(*logLevel); err != nil {
+			return nil, nil, err
+		}
 	}
-	return nil
+	opts := []lox.Option{lox.WithEnviron(os.LookupEnv)}
+	if *allowFiles != "" {
+		opts = append(opts, lox.WithFileAccess(strings.Split(*allowFiles, ",")...))
+	}
+	switch *trace {
+	case "":
+	case "text":
+		opts = append(opts, lox.WithTracer(lox.NewTextTracer(os.Stderr)))
+	case "json":
+		opts = append(opts, lox.WithTracer(lox.NewJSONTracer(os.Stderr)))
+	default:
+		return nil, nil, fmt.Errorf("invalid trace format %q", *trace)
+	}
+	var prof *profiling
+	if *profile || *profileFolded != "" {
+		prof = &profiling{profiler: lox.NewProfiler(), table: *profile, folded: *profileFolded}
+	}
+	return opts, prof, nil
 }
 
-func file(fpath string) error {
-	ctx := lox.NewContext(os.Stdout)
-
-	log.Debug().Msgf("(%s) executing %s", ctx.Phase(), fpath)
-	f, err := os.Open(fpath)
+func file(fpath string, args []string, opts []lox.Option, prof *profiling) error {
+	cancel, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	opts = append(opts, lox.WithContext(cancel), lox.WithStdin(os.Stdin), lox.WithArgs(args...))
+	if prof != nil {
+		opts = append(opts, lox.WithHook(prof.profiler))
+	}
+	interp, err := lox.NewInterpreter(opts...)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-
-	reader := bufio.NewReader(f)
 
-	err = execute(ctx, reader)
+	log.Debug().Msgf("executing %s", fpath)
+	_, err = interp.EvalFile(fpath)
+	if prof != nil {
+		if err := prof.report(); err != nil {
+			return err
+		}
+	}
 	if err != nil {
 		return fatalError{err}
 	}
 	return nil
 }
 
-func interactive() (err error) {
-	terminal, err := lox.NewTerminal(os.Stdin, "(lox) ")
+const (
+	prompt             = "(lox) "
+	continuationPrompt = "  ... "
+)
+
+func interactive(opts []lox.Option) (err error) {
+	terminal, err := lox.NewTerminal(os.Stdin, prompt)
 	if err != nil {
 		return err
 	}
@@ -81,9 +128,12 @@ func interactive() (err error) {
 		}
 	}()
 
-	ctx := lox.NewContext(terminal)
+	interp, err := lox.NewInterpreter(append(opts, lox.WithStdout(terminal), lox.WithStderr(terminal))...)
+	if err != nil {
+		return err
+	}
 
-	var line string
+	var line, pending string
 	for {
 		line, err = terminal.ReadLine()
 		if err == io.EOF {
@@ -91,10 +141,22 @@ func interactive() (err error) {
 		} else if err != nil {
 			return err
 		}
-		err = execute(ctx, strings.NewReader(line))
+		pending += line + "\n"
+		if lox.Incomplete(pending) {
+			if err := terminal.SetPrompt(continuationPrompt); err != nil {
+				return err
+			}
+			continue
+		}
+		src := pending
+		pending = ""
+		if err := terminal.SetPrompt(prompt); err != nil {
+			return err
+		}
+		_, err = interp.Eval(src)
 		if err == nil {
 			continue
-		} else if errors.Is(err, fatalError{}) {
+		} else if errors.Is(err, fatalError{}) || errors.As(err, &lox.ExitError{}) {
 			return err
 		} else if e := terminal.WriteError(err); e != nil {
 			return e
@@ -102,21 +164,6 @@ func interactive() (err error) {
 	}
 }
 
-func execute(ctx *lox.Context, reader io.Reader) error {
-	tokens, err := lox.Scan(ctx, bufio.NewReader(reader))
-	if err != nil {
-		return err
-	}
-	stmts, err := lox.Parse(ctx, tokens)
-	if err != nil {
-		return err
-	}
-	if err = lox.Typecheck(ctx, stmts); err != nil {
-		return err
-	}
-	return lox.Execute(ctx, stmts)
-}
-
 type fatalError struct {
 	Err error
 }
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+
+	"github.com/vdinovi/glox/lox"
+)
+
+// Reports the profile of a file's run as requested by the flags
+type profiling struct {
+	profiler *lox.Profiler
+	table    bool   // whether to print the table of timings to stderr
+	folded   string // file to which folded stacks are written, if any
+}
+
+func (p *profiling) report() error {
+	if p.table {
+		if err := p.profiler.WriteTable(os.Stderr); err != nil {
+			return err
+		}
+	}
+	if p.folded == "" {
+		return nil
+	}
+	f, err := os.Create(p.folded)
+	if err != nil {
+		return err
+	}
+	if err := p.profiler.WriteFolded(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/vdinovi/glox/lox"
+)
+
+// Runs the file named by args, recording its coverage if -coverage is given
+func run(args []string, opts []lox.Option, prof *profiling) error {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	coverage := flags.Bool("coverage", false, "record the statements executed and the branches taken")
+	coverageFile := flags.String("coverage-file", "lcov.info", "file to which the lcov coverage report is written")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("run requires a file")
+	}
+	fpath := flags.Arg(0)
+	if !*coverage {
+		return file(fpath, flags.Args()[1:], opts, prof)
+	}
+	if prof != nil {
+		return errors.New("coverage cannot be recorded while profiling")
+	}
+
+	src, err := os.Open(fpath)
+	if err != nil {
+		return err
+	}
+	cov, err := lox.NewCoverage(fpath, src)
+	src.Close()
+	if err != nil {
+		return fatalError{err}
+	}
+	err = file(fpath, flags.Args()[1:], append(opts, lox.WithHook(cov)), nil)
+
+	out, ferr := os.Create(*coverageFile)
+	if ferr != nil {
+		return ferr
+	}
+	if ferr := cov.WriteLCOV(out); ferr != nil {
+		out.Close()
+		return ferr
+	}
+	if ferr := out.Close(); ferr != nil {
+		return ferr
+	}
+	return err
+}
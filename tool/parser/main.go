@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
@@ -12,6 +13,8 @@ import (
 	"github.com/vdinovi/glox/tool"
 )
 
+var asJSON = flag.Bool("json", false, "print the program as JSON")
+
 func main() {
 	err := tool.Setup()
 	if err != nil {
@@ -43,6 +46,11 @@ func process(r io.Reader, w io.Writer) error {
 	if err != nil {
 		return err
 	}
+	if *asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(program)
+	}
 	for _, stmt := range program {
 		_, err := fmt.Fprintln(w, stmt.String())
 		if err != nil {